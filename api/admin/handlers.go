@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sentinel-official/dvpn-node/context"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func HandlerGetSessions(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		peers, err := ctx.Service().Peers()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(1, err))
+			return
+		}
+
+		m := make(map[string]types.Peer, len(peers))
+		for _, peer := range peers {
+			m[peer.Key] = peer
+		}
+
+		var items []types.Session
+		ctx.Database().Model(
+			&types.Session{},
+		).Order("id").Find(&items)
+
+		result := make([]*Session, 0, len(items))
+		for _, item := range items {
+			s := &Session{
+				ID:           item.ID,
+				Subscription: item.Subscription,
+				Key:          item.Key,
+				Address:      item.Address,
+				IPv4:         item.IPv4,
+				IPv6:         item.IPv6,
+				Available:    item.Available,
+				Download:     item.Download,
+				Upload:       item.Upload,
+				DownloadRate: item.DownloadRate,
+				UploadRate:   item.UploadRate,
+				CreatedAt:    item.CreatedAt,
+				UpdatedAt:    item.UpdatedAt,
+			}
+
+			if peer, ok := m[item.Key]; ok {
+				s.Connected = true
+				s.Download, s.Upload = peer.Download, peer.Upload
+			}
+
+			result = append(result, s)
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func HandlerGetSessionEvents(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetSessionEvents(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err))
+			return
+		}
+
+		var items []types.SessionEvent
+		ctx.Database().Model(
+			&types.SessionEvent{},
+		).Where(
+			&types.SessionEvent{
+				Session: req.URI.ID,
+			},
+		).Order("id").Find(&items)
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
+
+func HandlerDisconnectSession(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestDisconnectSession(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err))
+			return
+		}
+
+		var item types.Session
+		ctx.Database().Model(
+			&types.Session{},
+		).Where(
+			&types.Session{
+				ID:  req.Query.ID,
+				Key: req.Query.Key,
+			},
+		).First(&item)
+
+		if item.ID == 0 {
+			c.JSON(http.StatusNotFound, types.NewResponseError(2, "session does not exist"))
+			return
+		}
+
+		if err = ctx.RemoveSessionPeer(&item, "disconnected by the operator"); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(3, err))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(nil))
+	}
+}
+
+func HandlerFlushSessions(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := ctx.FlushSessions(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(1, err))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(nil))
+	}
+}
+
+func HandlerReloadPrices(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := ctx.ReloadPrices(); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(1, err))
+			return
+		}
+
+		result := &ResponseGetPrices{
+			GigabytePrices: ctx.GigabytePrices().String(),
+			HourlyPrices:   ctx.HourlyPrices().String(),
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func HandlerGetRemotes(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, types.NewResponseResult(ctx.Client().RemoteHealth()))
+	}
+}
+
+func HandlerGetEarnings(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetEarnings(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err))
+			return
+		}
+
+		var items []types.Earning
+		err = ctx.Database().Model(
+			&types.Earning{},
+		).Where(
+			"created_at >= ?", time.Now().Add(-req.Query.Since),
+		).Order("id").Find(&items).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err))
+			return
+		}
+
+		result, err := types.AggregateEarnings(items, req.Query.GroupBy)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(3, err))
+			return
+		}
+
+		if req.Query.Format == "csv" {
+			c.Header("Content-Type", "text/csv")
+			c.Header("Content-Disposition", "attachment; filename=earnings.csv")
+			if err = types.WriteEarningsCSV(c.Writer, req.Query.GroupBy, result...); err != nil {
+				ctx.Log().Error("failed to write the earnings", "error", err)
+			}
+
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
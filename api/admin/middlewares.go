@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sentinel-official/dvpn-node/context"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func MiddlewareBearerAuth(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := ctx.Config().Admin.Token
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		v := c.GetHeader("Authorization")
+		if !strings.HasPrefix(v, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, types.NewResponseError(1, errors.New("missing bearer token")))
+			return
+		}
+
+		v = strings.TrimPrefix(v, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(v), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, types.NewResponseError(1, errors.New("invalid bearer token")))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+type RequestDisconnectSession struct {
+	Query struct {
+		ID  uint64 `form:"id"`
+		Key string `form:"key"`
+	}
+}
+
+func NewRequestDisconnectSession(c *gin.Context) (req *RequestDisconnectSession, err error) {
+	req = &RequestDisconnectSession{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	if req.Query.ID == 0 && req.Query.Key == "" {
+		return nil, errors.New("id and key cannot be empty at the same time")
+	}
+
+	return req, nil
+}
+
+type RequestGetSessionEvents struct {
+	URI struct {
+		ID uint64 `uri:"id" binding:"gt=0"`
+	}
+}
+
+func NewRequestGetSessionEvents(c *gin.Context) (req *RequestGetSessionEvents, err error) {
+	req = &RequestGetSessionEvents{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetEarnings struct {
+	Query struct {
+		Format  string        `form:"format"`
+		GroupBy string        `form:"group_by"`
+		Since   time.Duration `form:"since"`
+	}
+}
+
+func NewRequestGetEarnings(c *gin.Context) (req *RequestGetEarnings, err error) {
+	req = &RequestGetEarnings{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	if req.Query.Format == "" {
+		req.Query.Format = "json"
+	}
+	if req.Query.GroupBy == "" {
+		req.Query.GroupBy = types.EarningGroupByDay
+	}
+	if req.Query.Since == 0 {
+		req.Query.Since = 720 * time.Hour
+	}
+
+	if req.Query.Format != "csv" && req.Query.Format != "json" {
+		return nil, fmt.Errorf("invalid format %s; expected csv or json", req.Query.Format)
+	}
+	if err = types.ValidateEarningGroupBy(req.Query.GroupBy); err != nil {
+		return nil, err
+	}
+	if req.Query.Since < 0 {
+		return nil, errors.New("since cannot be negative")
+	}
+
+	return req, nil
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"time"
+)
+
+type (
+	Session struct {
+		ID           uint64    `json:"id"`
+		Subscription uint64    `json:"subscription"`
+		Key          string    `json:"key"`
+		Address      string    `json:"address"`
+		IPv4         string    `json:"ipv4,omitempty"`
+		IPv6         string    `json:"ipv6,omitempty"`
+		Available    int64     `json:"available"`
+		Download     int64     `json:"download"`
+		Upload       int64     `json:"upload"`
+		DownloadRate int64     `json:"download_rate"`
+		UploadRate   int64     `json:"upload_rate"`
+		Connected    bool      `json:"connected"`
+		CreatedAt    time.Time `json:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+	ResponseGetPrices struct {
+		GigabytePrices string `json:"gigabyte_prices"`
+		HourlyPrices   string `json:"hourly_prices"`
+	}
+)
@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/sentinel-official/dvpn-node/context"
+)
+
+func RegisterRoutes(ctx *context.Context, r gin.IRouter) {
+	r.Use(MiddlewareBearerAuth(ctx))
+
+	r.GET("/sessions", HandlerGetSessions(ctx))
+	r.GET("/sessions/:id/events", HandlerGetSessionEvents(ctx))
+	r.DELETE("/sessions", HandlerDisconnectSession(ctx))
+	r.POST("/sessions/flush", HandlerFlushSessions(ctx))
+	r.POST("/prices/reload", HandlerReloadPrices(ctx))
+	r.GET("/remotes", HandlerGetRemotes(ctx))
+	r.GET("/earnings", HandlerGetEarnings(ctx))
+}
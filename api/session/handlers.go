@@ -8,6 +8,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/gin-gonic/gin"
 	hubtypes "github.com/sentinel-official/hub/types"
+	sessiontypes "github.com/sentinel-official/hub/x/session/types"
 	subscriptiontypes "github.com/sentinel-official/hub/x/subscription/types"
 
 	"github.com/sentinel-official/dvpn-node/context"
@@ -148,6 +149,7 @@ func HandlerAddSession(ctx *context.Context) gin.HandlerFunc {
 
 		var (
 			checkAllocation       = true
+			hourly                = false
 			remainingBytes  int64 = 0
 		)
 
@@ -158,7 +160,7 @@ func HandlerAddSession(ctx *context.Context) gin.HandlerFunc {
 				return
 			}
 			if s.Hours != 0 {
-				checkAllocation = false
+				checkAllocation, hourly = false, true
 			}
 		}
 
@@ -214,7 +216,8 @@ func HandlerAddSession(ctx *context.Context) gin.HandlerFunc {
 		).Find(&items)
 
 		for i := 0; i < len(items); i++ {
-			if err = ctx.RemovePeerIfExists(items[i].Key); err != nil {
+			reason := fmt.Sprintf("replaced by session %d", req.URI.ID)
+			if err = ctx.RemoveSessionPeer(&items[i], reason); err != nil {
 				c.JSON(http.StatusInternalServerError, types.NewResponseError(9, err))
 				return
 			}
@@ -227,20 +230,134 @@ func HandlerAddSession(ctx *context.Context) gin.HandlerFunc {
 		}
 		ctx.Log().Info("Added a new peer", "key", req.Body.Key, "count", ctx.Service().PeerCount())
 
+		_, plan := subscription.(*subscriptiontypes.PlanSubscription)
+		downloadRate, uploadRate := ctx.Config().QOS.Rates(plan, hourly)
+
+		if err = ctx.Service().SetPeerRate(req.Key, downloadRate, uploadRate); err != nil {
+			if e := ctx.Service().RemovePeer(req.Key); e != nil {
+				ctx.Log().Error("failed to remove the peer from service", "error", e, "key", req.Body.Key)
+			}
+
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(10, err))
+			return
+		}
+
+		peer, _ := ctx.Service().GetPeer(req.Key)
+		newItem := &types.Session{
+			ID:           req.URI.ID,
+			Subscription: subscription.GetID(),
+			Key:          req.Body.Key,
+			Address:      req.URI.AccAddress,
+			IPv4:         peer.IPv4,
+			IPv6:         peer.IPv6,
+			Available:    remainingBytes,
+			DownloadRate: downloadRate,
+			UploadRate:   uploadRate,
+		}
+
 		ctx.Database().Model(
 			&types.Session{},
-		).Create(
-			&types.Session{
-				ID:           req.URI.ID,
-				Subscription: subscription.GetID(),
-				Key:          req.Body.Key,
-				Address:      req.URI.AccAddress,
-				Available:    remainingBytes,
-			},
-		)
+		).Create(newItem)
+		ctx.RecordSessionEvent(types.SessionEventAddPeer, newItem, "")
 
 		result = append(result, ctx.IPv4Address()...)
 		result = append(result, ctx.Service().Info()...)
 		c.JSON(http.StatusCreated, types.NewResponseResult(result))
 	}
 }
+
+func HandlerAddSessionProof(ctx *context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestAddSessionProof(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err))
+			return
+		}
+
+		item := types.Session{}
+		ctx.Database().Model(
+			&types.Session{},
+		).Where(
+			&types.Session{
+				ID: req.URI.ID,
+			},
+		).First(&item)
+
+		if item.ID == 0 {
+			err = fmt.Errorf("session %d does not exist", req.URI.ID)
+			c.JSON(http.StatusNotFound, types.NewResponseError(2, err))
+			return
+		}
+		if item.Address != req.URI.AccAddress {
+			err = fmt.Errorf("account address mismatch; expected %s, got %s", item.Address, req.URI.AccAddress)
+			c.JSON(http.StatusBadRequest, types.NewResponseError(2, err))
+			return
+		}
+		if req.Body.Duration < item.SignedDuration ||
+			req.Body.Download < item.SignedDownload ||
+			req.Body.Upload < item.SignedUpload {
+			err = fmt.Errorf("proof is older than the latest signed proof of session %d", item.ID)
+			c.JSON(http.StatusBadRequest, types.NewResponseError(3, err))
+			return
+		}
+		if item.TrailedBy(req.Body.Duration, req.Body.Download, req.Body.Upload) {
+			err = fmt.Errorf("proof is well below the counters of session %d; duration %s, download %d, upload %d",
+				item.ID, item.Duration(), item.Download, item.Upload)
+			c.JSON(http.StatusBadRequest, types.NewResponseError(3, err))
+			return
+		}
+
+		account, err := ctx.Client().QueryAccount(req.AccAddress)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(4, err))
+			return
+		}
+		if account == nil {
+			err = fmt.Errorf("account %s does not exist", req.AccAddress)
+			c.JSON(http.StatusNotFound, types.NewResponseError(4, err))
+			return
+		}
+		if account.GetPubKey() == nil {
+			err = fmt.Errorf("public key for account %s does not exist", req.AccAddress)
+			c.JSON(http.StatusNotFound, types.NewResponseError(4, err))
+			return
+		}
+
+		proof := sessiontypes.Proof{
+			ID:        item.ID,
+			Duration:  req.Body.Duration,
+			Bandwidth: hubtypes.NewBandwidthFromInt64(req.Body.Upload, req.Body.Download),
+		}
+
+		msg, err := proof.Marshal()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(5, err))
+			return
+		}
+
+		if ok := account.GetPubKey().VerifySignature(msg, req.Signature); !ok {
+			err = fmt.Errorf("invalid signature %s", req.Body.Signature)
+			c.JSON(http.StatusBadRequest, types.NewResponseError(5, err))
+			return
+		}
+
+		// The columns are updated without the timestamp, which measures the
+		// duration of the session and moves only with its traffic.
+		ctx.Database().Model(
+			&types.Session{},
+		).Where(
+			&types.Session{
+				ID: item.ID,
+			},
+		).UpdateColumns(
+			&types.Session{
+				SignedDuration: req.Body.Duration,
+				SignedDownload: req.Body.Download,
+				SignedUpload:   req.Body.Upload,
+				Signature:      req.Signature,
+			},
+		)
+
+		c.JSON(http.StatusOK, types.NewResponseResult(nil))
+	}
+}
@@ -0,0 +1,220 @@
+package session
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/gin-gonic/gin"
+	"github.com/gogo/gateway"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	hubtypes "github.com/sentinel-official/hub/types"
+	sessiontypes "github.com/sentinel-official/hub/x/session/types"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/sentinel-official/dvpn-node/context"
+	"github.com/sentinel-official/dvpn-node/lite"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+// fakeAuthQuery serves the accounts of the clients.
+type fakeAuthQuery struct {
+	authtypes.UnimplementedQueryServer
+
+	accounts map[string]authtypes.AccountI
+}
+
+func (q *fakeAuthQuery) Account(_ gocontext.Context, req *authtypes.QueryAccountRequest) (*authtypes.QueryAccountResponse, error) {
+	item, ok := q.accounts[req.Address]
+	if !ok {
+		return nil, fmt.Errorf("account %s not found", req.Address)
+	}
+
+	v, err := codectypes.NewAnyWithValue(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authtypes.QueryAccountResponse{Account: v}, nil
+}
+
+// newFakeLCDRemote serves the accounts through the routes of the REST
+// gateway.
+func newFakeLCDRemote(t *testing.T, query *fakeAuthQuery) string {
+	t.Helper()
+
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(
+			runtime.MIMEWildcard,
+			&gateway.JSONPb{
+				EmitDefaults: true,
+				OrigName:     true,
+				AnyResolver:  lite.DefaultEncodingConfig().InterfaceRegistry,
+			},
+		),
+	)
+	if err := authtypes.RegisterQueryHandlerServer(gocontext.Background(), mux, query); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return "lcd+" + server.URL
+}
+
+func TestHandlerAddSessionProof(t *testing.T) {
+	const (
+		download = 50 << 20
+		upload   = 10 << 20
+		duration = 10 * time.Minute
+	)
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		download int64
+		upload   int64
+	}{
+		{
+			name:     "covering the counters",
+			duration: duration,
+			download: download,
+			upload:   upload,
+		},
+		{
+			name:     "trailing the counters within the tolerance",
+			duration: duration - 30*time.Second,
+			download: download - 1<<20,
+			upload:   upload - 512<<10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kr := keyring.NewInMemory()
+			info, _, err := kr.NewMnemonic("test", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			remote := newFakeLCDRemote(t, &fakeAuthQuery{
+				accounts: map[string]authtypes.AccountI{
+					info.GetAddress().String(): authtypes.NewBaseAccount(info.GetAddress(), info.GetPubKey(), 5, 7),
+				},
+			})
+
+			database, err := gorm.Open(
+				sqlite.Open(filepath.Join(t.TempDir(), "data.db")),
+				&gorm.Config{Logger: logger.Discard},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = database.AutoMigrate(&types.Session{}); err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				now  = time.Now().UTC().Truncate(time.Second)
+				item = types.Session{
+					ID:           1,
+					Subscription: 2,
+					Key:          "key",
+					Address:      info.GetAddress().String(),
+					Download:     download,
+					Upload:       upload,
+				}
+			)
+
+			item.CreatedAt, item.UpdatedAt = now.Add(-duration), now
+			if err = database.Create(&item).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.NewContext().
+				WithClient(
+					lite.NewDefaultClient().
+						WithLogger(tmlog.NewNopLogger()).
+						WithQueryTimeout(5).
+						WithRemotes([]string{remote}),
+				).
+				WithDatabase(database).
+				WithLogger(tmlog.NewNopLogger())
+
+			proof := sessiontypes.Proof{
+				ID:        item.ID,
+				Duration:  tt.duration,
+				Bandwidth: hubtypes.NewBandwidthFromInt64(tt.upload, tt.download),
+			}
+
+			msg, err := proof.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signature, _, err := kr.Sign(info.GetName(), msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := json.Marshal(map[string]interface{}{
+				"duration":  tt.duration,
+				"download":  tt.download,
+				"upload":    tt.upload,
+				"signature": base64.StdEncoding.EncodeToString(signature),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			RegisterRoutes(ctx, router)
+
+			var (
+				path = fmt.Sprintf("/accounts/%s/sessions/%d/proofs", info.GetAddress(), item.ID)
+				req  = httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+				rec  = httptest.NewRecorder()
+			)
+
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+
+			var stored types.Session
+			if err = database.First(&stored, item.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.Duration() != duration {
+				t.Fatalf("expected the duration %s to be kept, got %s", duration, stored.Duration())
+			}
+
+			queued := types.NewQueuedProof(&stored)
+			if !bytes.Equal(queued.Signature, signature) {
+				t.Fatal("expected the signature of the client to be attached")
+			}
+			if queued.Duration != tt.duration || queued.Download != tt.download || queued.Upload != tt.upload {
+				t.Fatalf("expected the signed values, got duration %s, download %d, upload %d",
+					queued.Duration, queued.Download, queued.Upload)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sentinel-official/dvpn-node/metrics"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func MiddlewareAddSessionMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		var res struct {
+			Error *types.Error `json:"error"`
+		}
+
+		code := 0
+		if err := json.Unmarshal(w.body.Bytes(), &res); err != nil {
+			code = -1
+		} else if res.Error != nil {
+			code = res.Error.Code
+		}
+
+		metrics.AddSessionResults.WithLabelValues(strconv.Itoa(code)).Inc()
+	}
+}
@@ -2,6 +2,8 @@ package session
 
 import (
 	"encoding/base64"
+	"fmt"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/gin-gonic/gin"
@@ -46,3 +48,43 @@ func NewRequestAddSession(c *gin.Context) (req *RequestAddSession, err error) {
 
 	return req, nil
 }
+
+type RequestAddSessionProof struct {
+	AccAddress sdk.AccAddress
+	Signature  []byte
+
+	URI struct {
+		AccAddress string `uri:"acc_address"`
+		ID         uint64 `uri:"id" binding:"gt=0"`
+	}
+	Body struct {
+		Duration  time.Duration `json:"duration" binding:"gte=0"`
+		Download  int64         `json:"download" binding:"gte=0"`
+		Upload    int64         `json:"upload" binding:"gte=0"`
+		Signature string        `json:"signature"`
+	}
+}
+
+func NewRequestAddSessionProof(c *gin.Context) (req *RequestAddSessionProof, err error) {
+	req = &RequestAddSessionProof{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindJSON(&req.Body); err != nil {
+		return nil, err
+	}
+
+	req.AccAddress, err = sdk.AccAddressFromBech32(req.URI.AccAddress)
+	if err != nil {
+		return nil, err
+	}
+	req.Signature, err = base64.StdEncoding.DecodeString(req.Body.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Signature) != 64 {
+		return nil, fmt.Errorf("invalid signature length %d", len(req.Signature))
+	}
+
+	return req, nil
+}
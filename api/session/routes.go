@@ -7,5 +7,6 @@ import (
 )
 
 func RegisterRoutes(ctx *context.Context, router gin.IRouter) {
-	router.POST("/accounts/:acc_address/sessions/:id", HandlerAddSession(ctx))
+	router.POST("/accounts/:acc_address/sessions/:id", MiddlewareAddSessionMetrics(), HandlerAddSession(ctx))
+	router.POST("/accounts/:acc_address/sessions/:id/proofs", HandlerAddSessionProof(ctx))
 }
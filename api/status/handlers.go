@@ -39,6 +39,7 @@ func HandlerGetStatus(ctx *context.Context) gin.HandlerFunc {
 			QOS: &QOS{
 				MaxPeers: ctx.Config().QOS.MaxPeers,
 			},
+			Remote:  ctx.Client().ActiveRemote(),
 			Type:    ctx.Service().Type(),
 			Version: version.Version,
 		}
@@ -36,6 +36,7 @@ type (
 		GigabytePrices         string        `json:"gigabyte_prices"`
 		HourlyPrices           string        `json:"hourly_prices"`
 		QOS                    *QOS          `json:"qos"`
+		Remote                 string        `json:"remote"`
 		Type                   uint64        `json:"type"`
 		Version                string        `json:"version"`
 	}
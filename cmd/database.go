@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func openDatabase(path string) (*gorm.DB, error) {
+	database, err := gorm.Open(
+		sqlite.Open(path),
+		&gorm.Config{
+			Logger:      logger.Discard,
+			PrepareStmt: false,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.AutoMigrate(
+		&types.Earning{},
+		&types.Fee{},
+		&types.QueuedProof{},
+		&types.Session{},
+		&types.SessionEvent{},
+		&types.SpeedTest{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return database, nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func EarningsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "earnings",
+		Short: "Show the earnings of the sessions per day, account or subscription",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				home         = viper.GetString(flags.FlagHome)
+				databasePath = filepath.Join(home, types.DatabaseFileName)
+			)
+
+			groupBy, err := cmd.Flags().GetString(flagGroupBy)
+			if err != nil {
+				return err
+			}
+			if err = types.ValidateEarningGroupBy(groupBy); err != nil {
+				return err
+			}
+
+			output, err := cmd.Flags().GetString(flagOutput)
+			if err != nil {
+				return err
+			}
+
+			since, err := cmd.Flags().GetDuration(flagSince)
+			if err != nil {
+				return err
+			}
+
+			database, err := openDatabase(databasePath)
+			if err != nil {
+				return err
+			}
+
+			var items []types.Earning
+			err = database.Model(
+				&types.Earning{},
+			).Where(
+				"created_at >= ?", time.Now().Add(-since),
+			).Order("id").Find(&items).Error
+			if err != nil {
+				return err
+			}
+
+			result, err := types.AggregateEarnings(items, groupBy)
+			if err != nil {
+				return err
+			}
+
+			switch output {
+			case "csv":
+				return types.WriteEarningsCSV(cmd.OutOrStdout(), groupBy, result...)
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			case "text":
+				return writeEarnings(cmd.OutOrStdout(), groupBy, result...)
+			default:
+				return fmt.Errorf("invalid output %s; expected one of csv, json or text", output)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagGroupBy, types.EarningGroupByDay, "group the earnings by day, account or subscription")
+	cmd.Flags().String(flagOutput, "text", "output format (csv, json or text)")
+	cmd.Flags().Duration(flagSince, 720*time.Hour, "time period to summarise")
+
+	return cmd
+}
+
+func writeEarnings(w io.Writer, groupBy string, items ...types.EarningSummary) error {
+	tw := tabwriter.NewWriter(w, 1, 1, 1, ' ', 0)
+	header := strings.ToUpper(groupBy[:1]) + groupBy[1:]
+	if _, err := fmt.Fprintf(tw, "%s\tSessions\tBytes\tDuration\tPayout\n", header); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		_, err := fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n",
+			item.Key, item.Sessions, item.Bytes, item.Duration, item.Payout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/spf13/viper"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func TestEarningsCmd(t *testing.T) {
+	home := t.TempDir()
+	viper.Set(flags.FlagHome, home)
+	t.Cleanup(func() { viper.Set(flags.FlagHome, "") })
+
+	database, err := openDatabase(filepath.Join(home, types.DatabaseFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, item := range []types.Earning{
+		{CreatedAt: now.Add(-2 * time.Hour), Session: 1, Address: "sent1b", Bytes: 100, Duration: time.Minute, Payout: "5udvpn"},
+		{CreatedAt: now.Add(-time.Hour), Session: 2, Address: "sent1a", Bytes: 50, Duration: 90 * time.Second, Payout: "3uatom,1udvpn"},
+		{CreatedAt: now.Add(-time.Hour), Session: 1, Address: "sent1b", Bytes: 200, Duration: time.Minute, Payout: "7udvpn"},
+		// Left out by the period
+		{CreatedAt: now.Add(-48 * time.Hour), Session: 3, Address: "sent1a", Bytes: 1000, Payout: "100udvpn"},
+	} {
+		item := item
+		if err = database.Create(&item).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "csv",
+			output: "csv",
+			want: "account,sessions,bytes,duration_seconds,payout\n" +
+				"sent1a,1,50,90,\"3uatom,1udvpn\"\n" +
+				"sent1b,1,300,120,12udvpn\n",
+		},
+		{
+			name:   "json",
+			output: "json",
+			want: `[
+  {
+    "key": "sent1a",
+    "sessions": 1,
+    "bytes": 50,
+    "duration": 90000000000,
+    "payout": "3uatom,1udvpn"
+  },
+  {
+    "key": "sent1b",
+    "sessions": 1,
+    "bytes": 300,
+    "duration": 120000000000,
+    "payout": "12udvpn"
+  }
+]
+`,
+		},
+		{
+			name:   "text",
+			output: "text",
+			want: "Account Sessions Bytes Duration Payout\n" +
+				"sent1a  1        50    1m30s    3uatom,1udvpn\n" +
+				"sent1b  1        300   2m0s     12udvpn\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			cmd := EarningsCmd()
+			cmd.SetOut(&buf)
+			cmd.SetArgs([]string{"--group-by", types.EarningGroupByAccount, "--output", tt.output, "--since", "24h"})
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
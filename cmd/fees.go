@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func FeesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fees",
+		Short: "Transaction fee sub-commands",
+	}
+
+	cmd.AddCommand(
+		feesReport(),
+	)
+
+	return cmd
+}
+
+type feeSummary struct {
+	Type         string
+	Transactions int
+	GasUsed      int64
+	Amount       sdk.Coins
+}
+
+func feesReport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarise the transaction fees paid against the earnings",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				home         = viper.GetString(flags.FlagHome)
+				databasePath = filepath.Join(home, types.DatabaseFileName)
+			)
+
+			since, err := cmd.Flags().GetDuration(flagSince)
+			if err != nil {
+				return err
+			}
+
+			database, err := openDatabase(databasePath)
+			if err != nil {
+				return err
+			}
+
+			start := time.Now().Add(-since)
+
+			var items []types.Fee
+			err = database.Model(
+				&types.Fee{},
+			).Where(
+				"created_at >= ?", start,
+			).Find(&items).Error
+			if err != nil {
+				return err
+			}
+
+			var earnings []types.Earning
+			err = database.Model(
+				&types.Earning{},
+			).Where(
+				"created_at >= ?", start,
+			).Find(&earnings).Error
+			if err != nil {
+				return err
+			}
+
+			earned := sdk.NewCoins()
+			for i := range earnings {
+				earned = earned.Add(earnings[i].GetPayout()...)
+			}
+
+			return writeFeesReport(cmd.OutOrStdout(), items, earned)
+		},
+	}
+
+	cmd.Flags().Duration(flagSince, 720*time.Hour, "time period to summarise")
+
+	return cmd
+}
+
+func writeFeesReport(w io.Writer, items []types.Fee, earned sdk.Coins) error {
+	var (
+		spent   = sdk.NewCoins()
+		summary = make(map[string]*feeSummary)
+	)
+
+	for i := range items {
+		s, ok := summary[items[i].Type]
+		if !ok {
+			s = &feeSummary{Type: items[i].Type}
+			summary[items[i].Type] = s
+		}
+
+		amount := items[i].GetAmount()
+		s.Transactions++
+		s.GasUsed += items[i].GasUsed
+		s.Amount = s.Amount.Add(amount...)
+		spent = spent.Add(amount...)
+	}
+
+	list := make([]*feeSummary, 0, len(summary))
+	for _, s := range summary {
+		list = append(list, s)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Type < list[j].Type
+	})
+
+	tw := tabwriter.NewWriter(w, 1, 1, 1, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "Type\tTransactions\tGas used\tAmount"); err != nil {
+		return err
+	}
+
+	for _, s := range list {
+		_, err := fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", s.Type, s.Transactions, s.GasUsed, s.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(tw, "\nDenom\tSpent\tEarned\tNet"); err != nil {
+		return err
+	}
+
+	for _, denom := range denoms(spent, earned) {
+		var (
+			s = spent.AmountOf(denom)
+			e = earned.AmountOf(denom)
+		)
+
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", denom, s, e, e.Sub(s)); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func denoms(items ...sdk.Coins) []string {
+	m := make(map[string]bool)
+	for _, coins := range items {
+		for _, coin := range coins {
+			m[coin.Denom] = true
+		}
+	}
+
+	list := make([]string, 0, len(m))
+	for denom := range m {
+		list = append(list, denom)
+	}
+
+	sort.Strings(list)
+	return list
+}
@@ -2,7 +2,14 @@ package cmd
 
 const (
 	flagAccount              = "account"
+	flagAddress              = "address"
+	flagGroupBy              = "group-by"
+	flagID                   = "id"
+	flagKey                  = "key"
+	flagLimit                = "limit"
 	flagIndex                = "index"
+	flagOutput               = "output"
 	flagRecover              = "recover"
+	flagSince                = "since"
 	flagSkipConfigValidation = "skip-config-validation"
 )
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func SessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Sessions sub-commands",
+	}
+
+	cmd.AddCommand(
+		sessionsHistory(),
+	)
+
+	return cmd
+}
+
+func sessionsHistory() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the lifecycle events of the sessions",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				home         = viper.GetString(flags.FlagHome)
+				databasePath = filepath.Join(home, types.DatabaseFileName)
+			)
+
+			id, err := cmd.Flags().GetUint64(flagID)
+			if err != nil {
+				return err
+			}
+
+			key, err := cmd.Flags().GetString(flagKey)
+			if err != nil {
+				return err
+			}
+
+			address, err := cmd.Flags().GetString(flagAddress)
+			if err != nil {
+				return err
+			}
+
+			limit, err := cmd.Flags().GetInt(flagLimit)
+			if err != nil {
+				return err
+			}
+
+			database, err := openDatabase(databasePath)
+			if err != nil {
+				return err
+			}
+
+			var items []types.SessionEvent
+			err = database.Model(
+				&types.SessionEvent{},
+			).Where(
+				&types.SessionEvent{
+					Session: id,
+					Key:     key,
+					Address: address,
+				},
+			).Order("id DESC").Limit(limit).Find(&items).Error
+			if err != nil {
+				return err
+			}
+
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+
+			return writeSessionEvents(cmd.OutOrStdout(), items...)
+		},
+	}
+
+	cmd.Flags().Uint64(flagID, 0, "filter the events by session id")
+	cmd.Flags().String(flagKey, "", "filter the events by peer key")
+	cmd.Flags().String(flagAddress, "", "filter the events by account address")
+	cmd.Flags().Int(flagLimit, 100, "maximum number of events to show")
+
+	return cmd
+}
+
+func writeSessionEvents(w io.Writer, items ...types.SessionEvent) error {
+	tw := tabwriter.NewWriter(w, 1, 1, 1, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "Time\tSession\tType\tUpload\tDownload\tAddress\tKey\tReason"); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		_, err := fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			item.CreatedAt.UTC().Format(time.RFC3339), item.Session, item.Type,
+			item.Upload, item.Download, item.Address, item.Key, item.Reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
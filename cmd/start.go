@@ -10,17 +10,18 @@ import (
 
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 
 	"github.com/sentinel-official/dvpn-node/api"
+	"github.com/sentinel-official/dvpn-node/api/admin"
 	"github.com/sentinel-official/dvpn-node/context"
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth"
 	"github.com/sentinel-official/dvpn-node/libs/geoip"
+	geoiptypes "github.com/sentinel-official/dvpn-node/libs/geoip/types"
 	"github.com/sentinel-official/dvpn-node/lite"
 	"github.com/sentinel-official/dvpn-node/node"
 	"github.com/sentinel-official/dvpn-node/services/v2ray"
@@ -92,9 +93,9 @@ func StartCmd() *cobra.Command {
 					return err
 				}
 
-				service = wireguard.NewWireGuard(wgtypes.NewIPPool(ipv4Pool, ipv6Pool))
+				service = wireguard.NewWireGuard(wgtypes.NewIPPool(ipv4Pool, ipv6Pool)).WithLogger(log)
 			} else if config.Node.Type == "v2ray" {
-				service = v2ray.NewV2Ray()
+				service = v2ray.NewV2Ray().WithLogger(log)
 			}
 
 			var (
@@ -114,7 +115,9 @@ func StartCmd() *cobra.Command {
 			}
 
 			client := lite.NewDefaultClient().
+				WithBroadcastMode(config.Chain.BroadcastMode).
 				WithChainID(config.Chain.ID).
+				WithConfirmTimeout(config.Chain.TxConfirmTimeout).
 				WithFromAddress(info.GetAddress()).
 				WithFromName(config.Keyring.From).
 				WithGas(config.Chain.Gas).
@@ -128,6 +131,35 @@ func StartCmd() *cobra.Command {
 				WithSimulateAndExecute(config.Chain.SimulateAndExecute).
 				WithTxTimeout(config.Chain.RPCTxTimeout)
 
+			if config.Keyring.Granter != "" {
+				granter, err := sdk.AccAddressFromBech32(config.Keyring.Granter)
+				if err != nil {
+					return err
+				}
+
+				log.Info("Executing the messages on behalf of the granter", "granter", granter, "grantee", client.FromAddress())
+				client = client.WithAuthzGranter(granter)
+			}
+			if config.Chain.FeeGranter != "" {
+				feeGranter, err := sdk.AccAddressFromBech32(config.Chain.FeeGranter)
+				if err != nil {
+					return err
+				}
+
+				log.Info("Paying the fees through the fee granter", "granter", feeGranter)
+				client = client.WithFeeGranterAddress(feeGranter)
+			}
+			if config.Cache.Enable {
+				client = client.WithQueryCache(
+					lite.NewQueryCache(
+						config.Cache.MaxEntries,
+						config.Cache.AccountTTL,
+						config.Cache.PlanTTL,
+						config.Cache.StatusTTL,
+					),
+				)
+			}
+
 			account, err := client.QueryAccount(client.FromAddress())
 			if err != nil {
 				return err
@@ -136,19 +168,55 @@ func StartCmd() *cobra.Command {
 				return fmt.Errorf("account does not exist with address %s", client.FromAddress())
 			}
 
+			var providers []geoip.Provider
+			for _, name := range config.GeoIP.Providers {
+				switch name {
+				case types.GeoIPProviderIPAPI:
+					providers = append(providers, geoip.NewIPAPIProvider(config.GeoIP.Timeout))
+				case types.GeoIPProviderIPInfo:
+					providers = append(providers, geoip.NewIPInfoProvider(config.GeoIP.IPInfoToken, config.GeoIP.Timeout))
+				case types.GeoIPProviderMaxMind:
+					providers = append(providers, geoip.NewMaxMindProvider(config.GeoIP.MaxMindDatabase, config.Node.IPv4Address))
+				case types.GeoIPProviderStatic:
+					providers = append(providers,
+						geoip.NewStaticProvider(
+							geoiptypes.GeoIPLocation{
+								City:      config.GeoIP.StaticCity,
+								Country:   config.GeoIP.StaticCountry,
+								IP:        config.Node.IPv4Address,
+								Latitude:  config.GeoIP.StaticLatitude,
+								Longitude: config.GeoIP.StaticLongitude,
+							},
+						),
+					)
+				}
+			}
+
 			log.Info("Fetching the GeoIP location info...")
-			location, err := geoip.Location()
+			location, err := geoip.Location(filepath.Join(home, types.GeoIPFileName), providers...)
 			if err != nil {
 				return err
 			}
 			log.Info("GeoIP location info", "city", location.City, "country", location.Country)
 
 			log.Info("Performing the internet speed test...")
-			bandwidth, err := utils.FindInternetSpeed()
+			result, err := bandwidth.Probe(cmd.Context(), config.SpeedTest.BandwidthProbers()...)
 			if err != nil {
-				return err
+				if config.SpeedTest.RefuseBelowMin {
+					return err
+				}
+
+				log.Error("Internet speed test failed", "error", err)
+			} else {
+				log.Info("Internet speed test result", "data", result)
+				if err = config.SpeedTest.CheckMin(result.Upload, result.Download); err != nil {
+					if config.SpeedTest.RefuseBelowMin {
+						return err
+					}
+
+					log.Error("Internet speed is below the minimum", "error", err)
+				}
 			}
-			log.Info("Internet speed test result", "data", bandwidth)
 
 			if config.Handshake.Enable {
 				go func() {
@@ -172,22 +240,11 @@ func StartCmd() *cobra.Command {
 			}
 
 			log.Info("Opening the database", "path", databasePath)
-			database, err := gorm.Open(
-				sqlite.Open(databasePath),
-				&gorm.Config{
-					Logger:      logger.Discard,
-					PrepareStmt: false,
-				},
-			)
+			database, err := openDatabase(databasePath)
 			if err != nil {
 				return err
 			}
 
-			log.Info("Migrating the database models...")
-			if err = database.AutoMigrate(&types.Session{}); err != nil {
-				return err
-			}
-
 			var (
 				ctx            = context.NewContext()
 				router         = gin.New()
@@ -208,15 +265,29 @@ func StartCmd() *cobra.Command {
 			router.Use(corsMiddleware)
 			api.RegisterRoutes(ctx, router)
 
-			ctx = ctx.WithBandwidth(bandwidth).
+			adminRouter := gin.New()
+			admin.RegisterRoutes(ctx, adminRouter)
+
+			ctx = ctx.WithAdminHandler(adminRouter).
 				WithClient(client).
 				WithConfig(config).
 				WithDatabase(database).
 				WithHandler(router).
+				WithHome(home).
 				WithLocation(location).
 				WithLogger(log).
 				WithService(service)
 
+			if result != nil {
+				ctx.UpdateBandwidth(result)
+			} else {
+				ctx.RestoreBandwidth()
+			}
+
+			if err = ctx.RestorePeers(); err != nil {
+				return err
+			}
+
 			n := node.NewNode(ctx)
 			if err = n.Initialize(); err != nil {
 				return err
@@ -0,0 +1,62 @@
+package context
+
+import (
+	stdcontext "context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func (c *Context) FlushRequests() <-chan chan<- error {
+	return c.flushRequests
+}
+
+// FlushSessions asks the update_sessions job to submit the session proofs
+// right away and waits for the result.
+func (c *Context) FlushSessions(ctx stdcontext.Context) error {
+	reply := make(chan error, 1)
+
+	select {
+	case c.flushRequests <- reply:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Context) ReloadPrices() error {
+	c.Log().Info("Reloading the prices...")
+
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(c.Home(), types.ConfigFileName))
+
+	config, err := types.ReadInConfig(v)
+	if err != nil {
+		return err
+	}
+
+	if err = config.Node.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section node")
+	}
+
+	c.SetPrices(config.Node.GigabytePrices, config.Node.HourlyPrices)
+	if c.Config().Pricing.Enable {
+		prices, err := c.EvaluatePrices()
+		if err != nil {
+			return err
+		}
+
+		c.SetDynamicPrices(prices)
+	}
+
+	return c.UpdateNodeInfo()
+}
@@ -3,6 +3,8 @@ package context
 import (
 	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -11,50 +13,72 @@ import (
 	"gorm.io/gorm"
 
 	geoiptypes "github.com/sentinel-official/dvpn-node/libs/geoip/types"
+	pricingtypes "github.com/sentinel-official/dvpn-node/libs/pricing/types"
 	"github.com/sentinel-official/dvpn-node/lite"
 	"github.com/sentinel-official/dvpn-node/types"
 )
 
 type Context struct {
-	bandwidth *hubtypes.Bandwidth
-	client    *lite.Client
-	config    *types.Config
-	database  *gorm.DB
-	handler   http.Handler
-	location  *geoiptypes.GeoIPLocation
-	logger    tmlog.Logger
-	service   types.Service
+	adminHandler http.Handler
+	bandwidth    atomic.Pointer[hubtypes.Bandwidth]
+	client       *lite.Client
+	config       *types.Config
+	database     *gorm.DB
+	handler      http.Handler
+	home         string
+	location     *geoiptypes.GeoIPLocation
+	logger       tmlog.Logger
+	prices       *pricingtypes.Prices
+	service      types.Service
+
+	flushRequests chan chan<- error
+	mutex         sync.RWMutex
 }
 
 func NewContext() *Context {
-	return &Context{}
+	return &Context{
+		flushRequests: make(chan chan<- error),
+	}
 }
 
-func (c *Context) WithBandwidth(v *hubtypes.Bandwidth) *Context      { c.bandwidth = v; return c }
+func (c *Context) WithAdminHandler(v http.Handler) *Context          { c.adminHandler = v; return c }
+func (c *Context) WithBandwidth(v *hubtypes.Bandwidth) *Context      { c.SetBandwidth(v); return c }
 func (c *Context) WithClient(v *lite.Client) *Context                { c.client = v; return c }
 func (c *Context) WithConfig(v *types.Config) *Context               { c.config = v; return c }
 func (c *Context) WithDatabase(v *gorm.DB) *Context                  { c.database = v; return c }
 func (c *Context) WithHandler(v http.Handler) *Context               { c.handler = v; return c }
+func (c *Context) WithHome(v string) *Context                        { c.home = v; return c }
 func (c *Context) WithLocation(v *geoiptypes.GeoIPLocation) *Context { c.location = v; return c }
 func (c *Context) WithLogger(v tmlog.Logger) *Context                { c.logger = v; return c }
 func (c *Context) WithService(v types.Service) *Context              { c.service = v; return c }
 
 func (c *Context) Address() hubtypes.NodeAddress       { return c.Operator().Bytes() }
-func (c *Context) Bandwidth() *hubtypes.Bandwidth      { return c.bandwidth }
+func (c *Context) AdminHandler() http.Handler          { return c.adminHandler }
+func (c *Context) Bandwidth() *hubtypes.Bandwidth      { return c.bandwidth.Load() }
 func (c *Context) Client() *lite.Client                { return c.client }
 func (c *Context) Config() *types.Config               { return c.config }
 func (c *Context) Database() *gorm.DB                  { return c.database }
 func (c *Context) Handler() http.Handler               { return c.handler }
+func (c *Context) Home() string                        { return c.home }
 func (c *Context) IntervalSetSessions() time.Duration  { return c.Config().Node.IntervalSetSessions }
 func (c *Context) IntervalUpdateStatus() time.Duration { return c.Config().Node.IntervalUpdateStatus }
 func (c *Context) ListenOn() string                    { return c.Config().Node.ListenOn }
 func (c *Context) Location() *geoiptypes.GeoIPLocation { return c.location }
 func (c *Context) Log() tmlog.Logger                   { return c.logger }
 func (c *Context) Moniker() string                     { return c.Config().Node.Moniker }
-func (c *Context) Operator() sdk.AccAddress            { return c.client.FromAddress() }
 func (c *Context) RemoteURL() string                   { return c.Config().Node.RemoteURL }
 func (c *Context) Service() types.Service              { return c.service }
 
+// Operator returns the account of the node, which is the authz granter when
+// the transactions are signed by a hot key.
+func (c *Context) Operator() sdk.AccAddress {
+	if v := c.client.AuthzGranter(); v != nil {
+		return v
+	}
+
+	return c.client.FromAddress()
+}
+
 func (c *Context) IntervalUpdateSessions() time.Duration {
 	return c.Config().Node.IntervalUpdateSessions
 }
@@ -69,27 +93,73 @@ func (c *Context) IPv4Address() net.IP {
 }
 
 func (c *Context) GigabytePrices() sdk.Coins {
-	if c.Config().Node.GigabytePrices == "" {
-		return nil
-	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 
-	coins, err := sdk.ParseCoinsNormalized(c.Config().Node.GigabytePrices)
-	if err != nil {
-		panic(err)
+	if c.prices != nil {
+		return c.prices.Gigabyte
 	}
 
-	return coins
+	return c.basePrices().Gigabyte
 }
 
 func (c *Context) HourlyPrices() sdk.Coins {
-	if c.Config().Node.HourlyPrices == "" {
-		return nil
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.prices != nil {
+		return c.prices.Hourly
 	}
 
-	coins, err := sdk.ParseCoinsNormalized(c.Config().Node.HourlyPrices)
-	if err != nil {
-		panic(err)
+	return c.basePrices().Hourly
+}
+
+// BasePrices returns the prices of the config, which the pricing rules are
+// applied to.
+func (c *Context) BasePrices() *pricingtypes.Prices {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.basePrices()
+}
+
+func (c *Context) basePrices() *pricingtypes.Prices {
+	var (
+		err    error
+		prices = &pricingtypes.Prices{}
+	)
+
+	if v := c.Config().Node.GigabytePrices; v != "" {
+		prices.Gigabyte, err = sdk.ParseCoinsNormalized(v)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if v := c.Config().Node.HourlyPrices; v != "" {
+		prices.Hourly, err = sdk.ParseCoinsNormalized(v)
+		if err != nil {
+			panic(err)
+		}
 	}
 
-	return coins
+	return prices
+}
+
+// SetDynamicPrices overrides the prices of the config with the ones evaluated
+// by the pricing rules.
+func (c *Context) SetDynamicPrices(v *pricingtypes.Prices) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.prices = v
+}
+
+// SetPrices sets the prices of the config, which the pricing rules are applied
+// to; the dynamic prices are left for the caller to evaluate again.
+func (c *Context) SetPrices(gigabytePrices, hourlyPrices string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.Config().Node.GigabytePrices = gigabytePrices
+	c.Config().Node.HourlyPrices = hourlyPrices
 }
@@ -0,0 +1,78 @@
+package context
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	hubutils "github.com/sentinel-official/hub/utils"
+	subscriptiontypes "github.com/sentinel-official/hub/x/subscription/types"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+// setPayout sets the prices and the payout of the entry by the subscription of
+// the session. The gigabyte subscriptions of the node pay for the bytes at the
+// price of their deposit, while the hourly ones are paid upfront and accrue
+// over the duration of the sessions. The plan subscriptions do not pay the
+// node per session, so only the prices of the node are recorded.
+func (c *Context) setPayout(item *types.Earning, prev *types.Earning, subscription subscriptiontypes.Subscription) {
+	item.GigabytePrice = c.GigabytePrices().String()
+	item.HourlyPrice = c.HourlyPrices().String()
+
+	switch s := subscription.(type) {
+	case *subscriptiontypes.NodeSubscription:
+		item.Type = types.EarningTypeNode
+		if s.Gigabytes != 0 {
+			var (
+				price  = sdk.NewCoin(s.Deposit.Denom, s.Deposit.Amount.QuoRaw(s.Gigabytes))
+				amount = hubutils.AmountForBytes(price.Amount, sdk.NewInt(item.TotalBytes)).
+					Sub(hubutils.AmountForBytes(price.Amount, sdk.NewInt(prev.TotalBytes)))
+			)
+
+			item.GigabytePrice, item.HourlyPrice = price.String(), ""
+			if amount.IsPositive() {
+				item.Payout = sdk.NewCoins(sdk.NewCoin(price.Denom, amount)).String()
+			}
+		}
+		if s.Hours != 0 {
+			var (
+				price  = sdk.NewCoin(s.Deposit.Denom, s.Deposit.Amount.QuoRaw(s.Hours))
+				amount = sdk.NewDecFromInt(price.Amount).MulInt64(int64(item.Duration)).
+					QuoInt64(int64(time.Hour)).TruncateInt()
+			)
+
+			item.GigabytePrice, item.HourlyPrice = "", price.String()
+			if amount.IsPositive() {
+				item.Payout = sdk.NewCoins(sdk.NewCoin(price.Denom, amount)).String()
+			}
+		}
+	case *subscriptiontypes.PlanSubscription:
+		item.Type = types.EarningTypePlan
+		item.Plan = s.PlanID
+	}
+}
+
+// RecordEarning adds the ledger entry of a submitted session proof.
+func (c *Context) RecordEarning(proof *types.QueuedProof) {
+	var prev types.Earning
+	c.Database().Model(
+		&types.Earning{},
+	).Where(
+		&types.Earning{
+			Session: proof.Session,
+		},
+	).Order("id DESC").Limit(1).Find(&prev)
+
+	item := types.NewEarning(proof, &prev)
+
+	subscription, err := c.Client().QuerySubscription(proof.Subscription)
+	if err != nil {
+		c.Log().Error("failed to query the subscription of the earning", "error", err,
+			"id", proof.Session, "subscription", proof.Subscription)
+	}
+
+	c.setPayout(item, &prev, subscription)
+	if err = c.Database().Create(item).Error; err != nil {
+		c.Log().Error("failed to record the earning", "error", err, "id", proof.Session)
+	}
+}
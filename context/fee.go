@@ -0,0 +1,100 @@
+package context
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+// FeePayer returns the account paying the transaction fees, which is the fee
+// granter when one is set.
+func (c *Context) FeePayer() sdk.AccAddress {
+	if v := c.Client().FeeGranter(); v != nil {
+		return v
+	}
+
+	return c.Client().FromAddress()
+}
+
+// Tx broadcasts the messages and records the fee paid for the transaction.
+func (c *Context) Tx(t string, messages ...sdk.Msg) (*sdk.TxResponse, error) {
+	res, err := c.Client().Tx(messages...)
+
+	// A transaction which failed in DeliverTx, or was not confirmed after
+	// being accepted, is returned along with the error and may have paid its
+	// fee as well.
+	if res != nil && res.TxHash != "" && res.Tx != nil {
+		if err := c.recordFee(t, len(messages), res); err != nil {
+			c.Log().Error("failed to record the transaction fee", "error", err,
+				"type", t, "tx_hash", res.TxHash)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *Context) recordFee(t string, messages int, res *sdk.TxResponse) error {
+	gasPrices, err := sdk.ParseDecCoins(c.Config().Chain.GasPrices)
+	if err != nil {
+		return err
+	}
+
+	fee := types.NewFee(t, messages, res, gasPrices, c.FeePayer())
+	return c.Database().Create(fee).Error
+}
+
+// FeesSince returns the sum of the fees paid since the given time for the
+// transactions of the type, or of any type when it is empty.
+func (c *Context) FeesSince(since time.Time, t string) (sdk.Coins, error) {
+	var items []types.Fee
+	err := c.Database().Model(
+		&types.Fee{},
+	).Where(
+		&types.Fee{
+			Type: t,
+		},
+	).Where(
+		"created_at >= ?", since,
+	).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	total := sdk.NewCoins()
+	for i := range items {
+		total = total.Add(items[i].GetAmount()...)
+	}
+
+	return total, nil
+}
+
+// EstimateFee returns the fee of the last transaction of the type, or the fee
+// for the gas limit at the gas prices when there is none.
+func (c *Context) EstimateFee(t string) (sdk.Coins, error) {
+	var item types.Fee
+	err := c.Database().Model(
+		&types.Fee{},
+	).Where(
+		&types.Fee{
+			Type: t,
+		},
+	).Order("id DESC").Limit(1).Find(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	if item.ID != 0 {
+		return item.GetAmount(), nil
+	}
+
+	gasPrices, err := sdk.ParseDecCoins(c.Config().Chain.GasPrices)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.FeeForGas(int64(c.Config().Chain.Gas), gasPrices), nil
+}
@@ -0,0 +1,234 @@
+package context
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/gogo/protobuf/proto"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/sentinel-official/dvpn-node/lite"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+// gogoCodec marshals the gogo protobuf messages served by the fake remote.
+type gogoCodec struct{}
+
+func (gogoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal type %T", v)
+	}
+
+	return m.Marshal()
+}
+
+func (gogoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Unmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal type %T", v)
+	}
+
+	return m.Unmarshal(data)
+}
+
+func (gogoCodec) Name() string {
+	return "proto"
+}
+
+// fakeRemote serves a single account, accepts the broadcast transactions
+// unless checkCode is set, and reports them as included with deliverCode when
+// included is set.
+type fakeRemote struct {
+	authtypes.UnimplementedQueryServer
+	txtypes.UnimplementedServiceServer
+
+	account     authtypes.AccountI
+	checkCode   uint32
+	included    bool
+	deliverCode uint32
+}
+
+func (r *fakeRemote) Account(_ context.Context, _ *authtypes.QueryAccountRequest) (*authtypes.QueryAccountResponse, error) {
+	v, err := codectypes.NewAnyWithValue(r.account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authtypes.QueryAccountResponse{Account: v}, nil
+}
+
+func (r *fakeRemote) BroadcastTx(_ context.Context, req *txtypes.BroadcastTxRequest) (*txtypes.BroadcastTxResponse, error) {
+	return &txtypes.BroadcastTxResponse{
+		TxResponse: &sdk.TxResponse{
+			Code:      r.checkCode,
+			Codespace: sdkerrors.RootCodespace,
+			TxHash:    strings.ToUpper(hex.EncodeToString(tmhash.Sum(req.TxBytes))),
+		},
+	}, nil
+}
+
+func (r *fakeRemote) GetTx(_ context.Context, req *txtypes.GetTxRequest) (*txtypes.GetTxResponse, error) {
+	if !r.included {
+		return nil, status.Errorf(codes.NotFound, "tx %s not found", req.Hash)
+	}
+
+	return &txtypes.GetTxResponse{
+		TxResponse: &sdk.TxResponse{
+			Code:      r.deliverCode,
+			Codespace: sdkerrors.RootCodespace,
+			GasUsed:   150_000,
+			Height:    10,
+			TxHash:    req.Hash,
+		},
+	}, nil
+}
+
+func newTestContext(t *testing.T, remote *fakeRemote) *Context {
+	t.Helper()
+
+	kr := keyring.NewInMemory()
+	info, _, err := kr.NewMnemonic("test", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.account = authtypes.NewBaseAccount(info.GetAddress(), nil, 5, 7)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(gogoCodec{}))
+	authtypes.RegisterQueryServer(server, remote)
+	txtypes.RegisterServiceServer(server, remote)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	database, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "data.db")),
+		&gorm.Config{Logger: logger.Discard},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = database.AutoMigrate(&types.Fee{}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := lite.NewDefaultClient().
+		WithBroadcastMode(flags.BroadcastSync).
+		WithChainID("test").
+		WithConfirmTimeout(1).
+		WithFromAddress(info.GetAddress()).
+		WithFromName(info.GetName()).
+		WithGas(200_000).
+		WithGasPrices("0.1udvpn").
+		WithKeyring(kr).
+		WithLogger(tmlog.NewNopLogger()).
+		WithQueryTimeout(5).
+		WithRemotes([]string{"grpc://" + listener.Addr().String()}).
+		WithTxTimeout(5)
+
+	return NewContext().
+		WithClient(client).
+		WithConfig(types.NewConfig().WithDefaultValues()).
+		WithDatabase(database).
+		WithLogger(tmlog.NewNopLogger())
+}
+
+func TestContext_Tx(t *testing.T) {
+	tests := []struct {
+		name        string
+		remote      *fakeRemote
+		wantErr     bool
+		wantFee     bool
+		wantGasUsed int64
+	}{
+		{
+			name:        "included",
+			remote:      &fakeRemote{included: true},
+			wantFee:     true,
+			wantGasUsed: 150_000,
+		},
+		{
+			name:        "failed in DeliverTx",
+			remote:      &fakeRemote{included: true, deliverCode: sdkerrors.ErrOutOfGas.ABCICode()},
+			wantErr:     true,
+			wantFee:     true,
+			wantGasUsed: 150_000,
+		},
+		{
+			name:    "not confirmed after being accepted",
+			remote:  &fakeRemote{},
+			wantErr: true,
+			wantFee: true,
+		},
+		{
+			name:    "rejected in CheckTx",
+			remote:  &fakeRemote{checkCode: sdkerrors.ErrInsufficientFee.ABCICode()},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestContext(t, tt.remote)
+
+			msg := banktypes.NewMsgSend(c.Client().FromAddress(), c.Client().FromAddress(), sdk.NewCoins(sdk.NewInt64Coin("udvpn", 1)))
+			if _, err := c.Tx(types.FeeTypeUpdateSessions, msg); (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+
+			var items []types.Fee
+			if err := c.Database().Find(&items).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantFee {
+				if len(items) != 0 {
+					t.Fatalf("expected no fee to be recorded, got %v", items)
+				}
+
+				return
+			}
+
+			if len(items) != 1 {
+				t.Fatalf("expected a single fee to be recorded, got %v", items)
+			}
+			if items[0].TxHash == "" || items[0].Type != types.FeeTypeUpdateSessions {
+				t.Fatalf("expected the fee of the transaction, got %v", items[0])
+			}
+			if items[0].Amount != "20000udvpn" || items[0].GasWanted != 200_000 {
+				t.Fatalf("expected the signed fee, got %s for %d gas", items[0].Amount, items[0].GasWanted)
+			}
+			if items[0].GasUsed != tt.wantGasUsed {
+				t.Fatalf("expected %d gas used, got %d", tt.wantGasUsed, items[0].GasUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package context
+
+import (
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/libs/pricing"
+	pricingtypes "github.com/sentinel-official/dvpn-node/libs/pricing/types"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+// EvaluatePrices applies the pricing rules to the prices of the config.
+func (c *Context) EvaluatePrices() (*pricingtypes.Prices, error) {
+	input := &pricingtypes.Input{
+		Time:     time.Now(),
+		Peers:    c.Service().PeerCount(),
+		MaxPeers: c.Config().QOS.MaxPeers,
+	}
+
+	return pricing.Evaluate(input, c.BasePrices(), c.Config().Pricing.Rules()...)
+}
+
+// UpdatePrices evaluates the pricing rules and publishes the new prices when
+// any of them moved past the threshold, unless the fee of the transaction
+// would exceed the fee budget.
+func (c *Context) UpdatePrices() error {
+	prices, err := c.EvaluatePrices()
+	if err != nil {
+		return err
+	}
+
+	current := &pricingtypes.Prices{
+		Gigabyte: c.GigabytePrices(),
+		Hourly:   c.HourlyPrices(),
+	}
+
+	change := prices.Change(current)
+	if change.MustFloat64() < c.Config().Pricing.Threshold {
+		c.Log().Debug("Prices are within the threshold", "gigabyte_prices", prices.Gigabyte,
+			"hourly_prices", prices.Hourly, "change", change)
+		return nil
+	}
+
+	if budget := c.Config().Pricing.FeeBudgetCoins(); !budget.IsZero() {
+		spent, err := c.FeesSince(time.Now().Add(-c.Config().Pricing.BudgetPeriod), types.FeeTypeUpdateNodeInfo)
+		if err != nil {
+			return err
+		}
+
+		fee, err := c.EstimateFee(types.FeeTypeUpdateNodeInfo)
+		if err != nil {
+			return err
+		}
+
+		if !budget.IsAllGTE(spent.Add(fee...)) {
+			c.Log().Info("Skipping the price update to stay within the fee budget", "spent", spent,
+				"fee", fee, "budget", budget)
+			return nil
+		}
+	}
+
+	c.mutex.RLock()
+	prev := c.prices
+	c.mutex.RUnlock()
+
+	c.Log().Info("Publishing the prices", "gigabyte_prices", prices.Gigabyte,
+		"hourly_prices", prices.Hourly, "change", change)
+
+	c.SetDynamicPrices(prices)
+	if err = c.UpdateNodeInfo(); err != nil {
+		c.SetDynamicPrices(prev)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package context
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	hubtypes "github.com/sentinel-official/hub/types"
+	sessiontypes "github.com/sentinel-official/hub/x/session/types"
+
+	"github.com/sentinel-official/dvpn-node/lite"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func (c *Context) proofMessage(item *types.QueuedProof) sdk.Msg {
+	return sessiontypes.NewMsgUpdateDetailsRequest(
+		c.Address(),
+		sessiontypes.Proof{
+			ID:        item.Session,
+			Duration:  item.Duration,
+			Bandwidth: hubtypes.NewBandwidthFromInt64(item.Upload, item.Download),
+		},
+		item.Signature,
+	)
+}
+
+// submitProofs broadcasts the proofs in transactions within the gas limit of
+// the chain config. The chunks rejected by the chain are bisected to isolate
+// the bad proofs, and the proofs that could not be submitted are returned
+// along with their errors.
+func (c *Context) submitProofs(items []types.QueuedProof) map[uint64]error {
+	messages := make([]sdk.Msg, 0, len(items))
+	for i := range items {
+		messages = append(messages, c.proofMessage(&items[i]))
+	}
+
+	gas, err := c.Client().SimulateGas(messages...)
+	if err == nil && gas > c.Config().Chain.MaxTxGas && len(items) > 1 {
+		c.Log().Debug("Splitting the session proofs", "count", len(items), "gas", gas)
+		return c.bisectProofs(items)
+	}
+	if err == nil {
+		_, err = c.Tx(types.FeeTypeUpdateSessions, messages...)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if len(items) > 1 && lite.IsChainError(err) {
+		c.Log().Debug("Bisecting the rejected session proofs", "count", len(items), "error", err)
+		return c.bisectProofs(items)
+	}
+
+	failed := make(map[uint64]error)
+	for _, item := range items {
+		failed[item.Session] = err
+	}
+
+	return failed
+}
+
+func (c *Context) bisectProofs(items []types.QueuedProof) map[uint64]error {
+	failed := c.submitProofs(items[:len(items)/2])
+	for id, err := range c.submitProofs(items[len(items)/2:]) {
+		if failed == nil {
+			failed = make(map[uint64]error)
+		}
+
+		failed[id] = err
+	}
+
+	return failed
+}
@@ -2,6 +2,8 @@ package context
 
 import (
 	"encoding/base64"
+
+	"github.com/sentinel-official/dvpn-node/types"
 )
 
 func (c *Context) RemovePeer(key string) error {
@@ -43,3 +45,31 @@ func (c *Context) RemovePeerIfExists(key string) error {
 
 	return c.RemovePeer(key)
 }
+
+func (c *Context) RestorePeers() error {
+	var items []types.Session
+	c.Database().Model(
+		&types.Session{},
+	).Find(&items)
+
+	peers := make([]types.Peer, 0, len(items))
+	for _, item := range items {
+		peers = append(peers,
+			types.Peer{
+				Key:          item.Key,
+				IPv4:         item.IPv4,
+				IPv6:         item.IPv6,
+				DownloadRate: item.DownloadRate,
+				UploadRate:   item.UploadRate,
+			},
+		)
+	}
+
+	c.Log().Info("Restoring the peers", "count", len(peers))
+	if err := c.Service().RestorePeers(peers); err != nil {
+		c.Log().Error("failed to restore the peers", "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package context
+
+import (
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func (c *Context) RecordSessionEvent(t string, item *types.Session, reason string) {
+	event := types.NewSessionEvent(t, item, reason)
+	if err := c.Database().Create(event).Error; err != nil {
+		c.Log().Error("failed to record the session event", "error", err,
+			"type", t, "id", item.ID)
+	}
+}
+
+func (c *Context) RemoveSessionPeer(item *types.Session, reason string) error {
+	ok, err := c.HasPeer(item.Key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.Log().Debug("Peer does not exist", "key", item.Key)
+		return nil
+	}
+
+	if err = c.RemovePeer(item.Key); err != nil {
+		return err
+	}
+
+	c.RecordSessionEvent(types.SessionEventRemovePeer, item, reason)
+	return nil
+}
@@ -0,0 +1,71 @@
+package context
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	hubtypes "github.com/sentinel-official/hub/types"
+
+	bandwidthtypes "github.com/sentinel-official/dvpn-node/libs/bandwidth/types"
+	"github.com/sentinel-official/dvpn-node/metrics"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+func (c *Context) SetBandwidth(v *hubtypes.Bandwidth) {
+	c.bandwidth.Store(v)
+
+	metrics.Bandwidth.WithLabelValues("upload").Set(float64(v.Upload.Int64()))
+	metrics.Bandwidth.WithLabelValues("download").Set(float64(v.Download.Int64()))
+}
+
+func (c *Context) RecordSpeedTest(v *bandwidthtypes.Result) {
+	metrics.SpeedTestLatency.Set(v.Latency.Seconds())
+	metrics.SpeedTestJitter.Set(v.Jitter.Seconds())
+
+	result := &types.SpeedTest{
+		Prober:   v.Prober,
+		Upload:   v.Upload,
+		Download: v.Download,
+		Latency:  v.Latency,
+		Jitter:   v.Jitter,
+	}
+
+	if err := c.Database().Create(result).Error; err != nil {
+		c.Log().Error("failed to record the speed test", "error", err)
+	}
+}
+
+// UpdateBandwidth advertises the result of a speed test and keeps it in the
+// history.
+func (c *Context) UpdateBandwidth(v *bandwidthtypes.Result) {
+	c.SetBandwidth(
+		&hubtypes.Bandwidth{
+			Upload:   sdk.NewInt(v.Upload),
+			Download: sdk.NewInt(v.Download),
+		},
+	)
+
+	c.RecordSpeedTest(v)
+}
+
+// RestoreBandwidth advertises the result of the last recorded speed test, which
+// stands in for a failed one. Nothing is recorded, so failed speed tests do not
+// show up in the history.
+func (c *Context) RestoreBandwidth() {
+	var item types.SpeedTest
+	c.Database().Model(
+		&types.SpeedTest{},
+	).Order("id DESC").Limit(1).Find(&item)
+
+	if item.ID == 0 {
+		c.Log().Error("no speed test has been recorded; advertising zero bandwidth")
+	} else {
+		c.Log().Info("Advertising the last recorded speed test", "timestamp", item.CreatedAt,
+			"upload", item.Upload, "download", item.Download)
+	}
+
+	c.SetBandwidth(
+		&hubtypes.Bandwidth{
+			Upload:   sdk.NewInt(item.Upload),
+			Download: sdk.NewInt(item.Download),
+		},
+	)
+}
@@ -1,10 +1,11 @@
 package context
 
 import (
-	sdk "github.com/cosmos/cosmos-sdk/types"
+	"sort"
+
 	hubtypes "github.com/sentinel-official/hub/types"
 	nodetypes "github.com/sentinel-official/hub/x/node/types"
-	sessiontypes "github.com/sentinel-official/hub/x/session/types"
+	"gorm.io/gorm/clause"
 
 	"github.com/sentinel-official/dvpn-node/types"
 )
@@ -12,7 +13,8 @@ import (
 func (c *Context) RegisterNode() error {
 	c.Log().Info("Registering the node...")
 
-	_, err := c.Client().Tx(
+	_, err := c.Tx(
+		types.FeeTypeRegisterNode,
 		nodetypes.NewMsgRegisterRequest(
 			c.Operator(),
 			c.GigabytePrices(),
@@ -31,7 +33,8 @@ func (c *Context) RegisterNode() error {
 func (c *Context) UpdateNodeInfo() error {
 	c.Log().Info("Updating the node info...")
 
-	_, err := c.Client().Tx(
+	_, err := c.Tx(
+		types.FeeTypeUpdateNodeInfo,
 		nodetypes.NewMsgUpdateDetailsRequest(
 			c.Address(),
 			c.GigabytePrices(),
@@ -50,7 +53,8 @@ func (c *Context) UpdateNodeInfo() error {
 func (c *Context) UpdateNodeStatus() error {
 	c.Log().Info("Updating the node status...")
 
-	_, err := c.Client().Tx(
+	_, err := c.Tx(
+		types.FeeTypeUpdateNodeStatus,
 		nodetypes.NewMsgUpdateStatusRequest(
 			c.Address(),
 			hubtypes.StatusActive,
@@ -65,29 +69,91 @@ func (c *Context) UpdateNodeStatus() error {
 }
 
 func (c *Context) UpdateSessions(items ...types.Session) error {
-	c.Log().Info("Updating the sessions...")
-
-	messages := make([]sdk.Msg, 0, len(items))
-	for _, item := range items {
-		messages = append(messages,
-			sessiontypes.NewMsgUpdateDetailsRequest(
-				c.Address(),
-				sessiontypes.Proof{
-					ID:        item.ID,
-					Duration:  item.UpdatedAt.Sub(item.CreatedAt),
-					Bandwidth: hubtypes.NewBandwidthFromInt64(item.Upload, item.Download),
+	var queued []types.QueuedProof
+	if err := c.Database().Find(&queued).Error; err != nil {
+		return err
+	}
+
+	proofs := make(map[uint64]types.QueuedProof)
+	for _, item := range queued {
+		proofs[item.Session] = item
+	}
+	for i := range items {
+		proof := types.NewQueuedProof(&items[i])
+		if v, ok := proofs[proof.Session]; ok {
+			proof.CreatedAt, proof.Attempts = v.CreatedAt, v.Attempts
+		}
+
+		proofs[proof.Session] = *proof
+	}
+
+	if len(proofs) == 0 {
+		return nil
+	}
+
+	c.Log().Info("Updating the sessions...", "count", len(proofs), "queued", len(queued))
+
+	list := make([]types.QueuedProof, 0, len(proofs))
+	for _, proof := range proofs {
+		list = append(list, proof)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Session < list[j].Session
+	})
+
+	failed := c.submitProofs(list)
+	for i := range list {
+		proof := &list[i]
+
+		err, ok := failed[proof.Session]
+		if !ok {
+			c.Database().Where(
+				&types.QueuedProof{
+					Session: proof.Session,
+				},
+			).Delete(
+				&types.QueuedProof{},
+			)
+
+			c.RecordSessionEvent(types.SessionEventProofSubmitted, proof.GetSession(), "")
+			c.RecordEarning(proof)
+			continue
+		}
+
+		proof.Attempts++
+		proof.Error = err.Error()
+
+		if proof.Attempts >= types.MaxProofAttempts {
+			c.Log().Error("Dropping the session proof", "id", proof.Session,
+				"attempts", proof.Attempts, "error", err)
+
+			c.Database().Where(
+				&types.QueuedProof{
+					Session: proof.Session,
 				},
-				nil,
-			),
-		)
+			).Delete(
+				&types.QueuedProof{},
+			)
+
+			c.RecordSessionEvent(types.SessionEventProofDropped, proof.GetSession(), proof.Error)
+			continue
+		}
+
+		err = c.Database().Clauses(
+			clause.OnConflict{
+				UpdateAll: true,
+			},
+		).Create(proof).Error
+		if err != nil {
+			c.Log().Error("failed to queue the session proof", "id", proof.Session, "error", err)
+		}
+
+		c.RecordSessionEvent(types.SessionEventProofFailed, proof.GetSession(), proof.Error)
 	}
 
-	_, err := c.Client().Tx(
-		messages...,
-	)
-	if err != nil {
-		c.Log().Error("failed to update the sessions", "error", err)
-		return err
+	if len(failed) > 0 {
+		c.Log().Error("failed to update the sessions", "failed", len(failed), "total", len(list))
 	}
 
 	return nil
@@ -0,0 +1,83 @@
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth/types"
+)
+
+const (
+	pingCount = 5
+)
+
+type BandwidthProber interface {
+	Name() string
+	Probe(ctx context.Context) (*types.Result, error)
+}
+
+// Probe asks the probers in order and returns the first complete result.
+func Probe(ctx context.Context, probers ...BandwidthProber) (*types.Result, error) {
+	var errs []string
+	for _, prober := range probers {
+		result, err := prober.Probe(ctx)
+		if err == nil && result.IsAnyZero() {
+			err = fmt.Errorf("incomplete result; upload %d, download %d", result.Upload, result.Download)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			errs = append(errs, fmt.Sprintf("%s: %s", prober.Name(), err))
+			continue
+		}
+
+		result.Prober = prober.Name()
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("failed to measure the bandwidth; %s", strings.Join(errs, "; "))
+}
+
+// ping measures the time to open a TCP connection to the address a few times
+// and returns the mean and the mean variation between consecutive samples.
+func ping(ctx context.Context, address string) (latency, jitter time.Duration, err error) {
+	var (
+		dialer  net.Dialer
+		samples = make([]time.Duration, 0, pingCount)
+	)
+
+	for i := 0; i < pingCount; i++ {
+		start := time.Now()
+
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		samples = append(samples, time.Since(start))
+		_ = conn.Close()
+	}
+
+	for i, sample := range samples {
+		latency += sample
+		if i > 0 {
+			diff := sample - samples[i-1]
+			if diff < 0 {
+				diff = -diff
+			}
+
+			jitter += diff
+		}
+	}
+
+	return latency / time.Duration(len(samples)), jitter / time.Duration(len(samples)-1), nil
+}
+
+func mbitToBytes(v float64) int64 {
+	return int64(v * 1e6 / 8)
+}
@@ -0,0 +1,160 @@
+package bandwidth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth/types"
+)
+
+var (
+	_ BandwidthProber = (*HTTPProber)(nil)
+)
+
+// HTTPProber measures the bandwidth against a self-hosted endpoint, reading
+// from the download URL and streaming random data to the upload URL for the
+// given duration each.
+type HTTPProber struct {
+	client      *http.Client
+	downloadURL string
+	uploadURL   string
+	duration    time.Duration
+}
+
+func NewHTTPProber(downloadURL, uploadURL string, duration time.Duration) *HTTPProber {
+	return &HTTPProber{
+		client:      &http.Client{},
+		downloadURL: downloadURL,
+		uploadURL:   uploadURL,
+		duration:    duration,
+	}
+}
+
+func (p *HTTPProber) Name() string {
+	return "http"
+}
+
+func (p *HTTPProber) download(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.duration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.downloadURL, http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %s from %s", resp.Status, p.downloadURL)
+	}
+
+	n, err := io.Copy(io.Discard, resp.Body)
+	if err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 0, err
+	}
+
+	return int64(float64(n) / time.Since(start).Seconds()), nil
+}
+
+// countingReader produces random data until the deadline and counts the bytes
+// handed out.
+type countingReader struct {
+	deadline time.Time
+	n        int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	if time.Now().After(r.deadline) {
+		return 0, io.EOF
+	}
+
+	n, err := rand.Read(b)
+	r.n += int64(n)
+
+	return n, err
+}
+
+func (p *HTTPProber) upload(ctx context.Context) (int64, error) {
+	var (
+		start = time.Now()
+		body  = &countingReader{deadline: start.Add(p.duration)}
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.uploadURL, body)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("unexpected status %s from %s", resp.Status, p.uploadURL)
+	}
+
+	return int64(float64(body.n) / time.Since(start).Seconds()), nil
+}
+
+func (p *HTTPProber) Probe(ctx context.Context) (*types.Result, error) {
+	u, err := url.Parse(p.downloadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	latency, jitter, err := ping(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := p.download(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := p.upload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Result{
+		Upload:   upload,
+		Download: download,
+		Latency:  latency,
+		Jitter:   jitter,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package bandwidth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth/types"
+)
+
+var (
+	_ BandwidthProber = (*Iperf3Prober)(nil)
+)
+
+// Iperf3Prober measures the bandwidth against an iperf3 server with the
+// iperf3 client found in the PATH.
+type Iperf3Prober struct {
+	address  string
+	duration time.Duration
+}
+
+func NewIperf3Prober(address string, duration time.Duration) *Iperf3Prober {
+	return &Iperf3Prober{
+		address:  address,
+		duration: duration,
+	}
+}
+
+func (p *Iperf3Prober) Name() string {
+	return "iperf3"
+}
+
+// run returns the throughput received at the far end in bytes per second;
+// reverse measures the traffic sent by the server to the node.
+func (p *Iperf3Prober) run(ctx context.Context, reverse bool) (int64, error) {
+	host, port, err := net.SplitHostPort(p.address)
+	if err != nil {
+		return 0, err
+	}
+
+	args := []string{
+		"--client", host,
+		"--port", port,
+		"--time", strconv.Itoa(int(p.duration.Seconds())),
+		"--json",
+	}
+	if reverse {
+		args = append(args, "--reverse")
+	}
+
+	output, err := exec.CommandContext(ctx, "iperf3", args...).Output()
+	if err != nil && len(output) == 0 {
+		return 0, err
+	}
+
+	var report struct {
+		Error string `json:"error"`
+		End   struct {
+			SumReceived struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_received"`
+		} `json:"end"`
+	}
+
+	if err = json.Unmarshal(output, &report); err != nil {
+		return 0, err
+	}
+	if report.Error != "" {
+		return 0, errors.New(report.Error)
+	}
+
+	return int64(report.End.SumReceived.BitsPerSecond / 8), nil
+}
+
+func (p *Iperf3Prober) Probe(ctx context.Context) (*types.Result, error) {
+	latency, jitter, err := ping(ctx, p.address)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := p.run(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := p.run(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Result{
+		Upload:   upload,
+		Download: download,
+		Latency:  latency,
+		Jitter:   jitter,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package bandwidth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/showwin/speedtest-go/speedtest"
+
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth/types"
+)
+
+var (
+	_ BandwidthProber = (*SpeedTestProber)(nil)
+)
+
+type SpeedTestProber struct {
+	servers []int
+}
+
+func NewSpeedTestProber(servers ...int) *SpeedTestProber {
+	return &SpeedTestProber{
+		servers: servers,
+	}
+}
+
+func (p *SpeedTestProber) Name() string {
+	return "speedtest"
+}
+
+func (p *SpeedTestProber) Probe(ctx context.Context) (*types.Result, error) {
+	client := speedtest.New()
+	if _, err := client.FetchUserInfoContext(ctx); err != nil {
+		return nil, err
+	}
+
+	servers, err := client.FetchServerListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err = servers.FindServer(p.servers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = errors.New("no servers available")
+	for _, s := range servers {
+		s.Context.Reset()
+		if err = s.PingTestContext(ctx, nil); err != nil {
+			continue
+		}
+
+		if err = s.DownloadTestContext(ctx); err != nil {
+			continue
+		}
+		s.Context.Wait()
+
+		if err = s.UploadTestContext(ctx); err != nil {
+			continue
+		}
+		s.Context.Wait()
+
+		result := &types.Result{
+			Upload:   mbitToBytes(s.ULSpeed),
+			Download: mbitToBytes(s.DLSpeed),
+			Latency:  s.Latency,
+			Jitter:   s.Jitter,
+		}
+
+		if !result.IsAnyZero() {
+			return result, nil
+		}
+
+		err = errors.New("incomplete result")
+	}
+
+	return nil, err
+}
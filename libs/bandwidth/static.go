@@ -0,0 +1,34 @@
+package bandwidth
+
+import (
+	"context"
+
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth/types"
+)
+
+var (
+	_ BandwidthProber = (*StaticProber)(nil)
+)
+
+type StaticProber struct {
+	upload   int64
+	download int64
+}
+
+func NewStaticProber(upload, download int64) *StaticProber {
+	return &StaticProber{
+		upload:   upload,
+		download: download,
+	}
+}
+
+func (p *StaticProber) Name() string {
+	return "static"
+}
+
+func (p *StaticProber) Probe(_ context.Context) (*types.Result, error) {
+	return &types.Result{
+		Upload:   p.upload,
+		Download: p.download,
+	}, nil
+}
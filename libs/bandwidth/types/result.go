@@ -0,0 +1,18 @@
+package types
+
+import (
+	"time"
+)
+
+// Result holds the speeds in bytes per second.
+type Result struct {
+	Prober   string        `json:"prober"`
+	Upload   int64         `json:"upload"`
+	Download int64         `json:"download"`
+	Latency  time.Duration `json:"latency"`
+	Jitter   time.Duration `json:"jitter"`
+}
+
+func (r *Result) IsAnyZero() bool {
+	return r.Upload <= 0 || r.Download <= 0
+}
@@ -2,21 +2,29 @@ package geoip
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/sentinel-official/dvpn-node/libs/geoip/types"
 )
 
-func Location() (*types.GeoIPLocation, error) {
-	var (
-		client = &http.Client{Timeout: 15 * time.Second}
-		path   = "http://ip-api.com/json"
-	)
+const (
+	DefaultTimeout = 15 * time.Second
+)
+
+type Provider interface {
+	Name() string
+	Location() (*types.GeoIPLocation, error)
+}
 
-	resp, err := client.Get(path)
+func getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer func() {
@@ -25,23 +33,65 @@ func Location() (*types.GeoIPLocation, error) {
 		}
 	}()
 
-	var body struct {
-		City      string  `json:"city"`
-		Country   string  `json:"country"`
-		IP        string  `json:"query"`
-		Latitude  float64 `json:"lat"`
-		Longitude float64 `json:"lon"`
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Location asks the providers in order and returns the first location found.
+// The result is saved to the cache file, which serves as the last resort when
+// none of the providers succeed.
+func Location(cacheFile string, providers ...Provider) (*types.GeoIPLocation, error) {
+	var errs []string
+	for _, provider := range providers {
+		location, err := provider.Location()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", provider.Name(), err))
+			continue
+		}
+
+		if cacheFile != "" {
+			// Failing to cache only costs the fallback on the next start
+			_ = writeCache(cacheFile, location)
+		}
+
+		return location, nil
 	}
 
-	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+	if cacheFile != "" {
+		location, err := readCache(cacheFile)
+		if err == nil {
+			return location, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Sprintf("cache: %s", err))
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find the location; %s", strings.Join(errs, "; "))
+}
+
+func readCache(path string) (*types.GeoIPLocation, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
 
-	return &types.GeoIPLocation{
-		City:      body.City,
-		Country:   body.Country,
-		IP:        body.IP,
-		Latitude:  body.Latitude,
-		Longitude: body.Longitude,
-	}, nil
+	var location types.GeoIPLocation
+	if err = json.Unmarshal(buf, &location); err != nil {
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+func writeCache(path string, location *types.GeoIPLocation) error {
+	buf, err := json.Marshal(location)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf, 0644)
 }
@@ -0,0 +1,155 @@
+package geoip
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sentinel-official/dvpn-node/libs/geoip/types"
+)
+
+var (
+	_ Provider = (*fakeProvider)(nil)
+)
+
+type fakeProvider struct {
+	name     string
+	location *types.GeoIPLocation
+	err      error
+	calls    int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Location() (*types.GeoIPLocation, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	location := *p.location
+	return &location, nil
+}
+
+func TestLocation_Order(t *testing.T) {
+	var (
+		first = &fakeProvider{
+			name: "first",
+			err:  errors.New("unavailable"),
+		}
+		second = &fakeProvider{
+			name:     "second",
+			location: &types.GeoIPLocation{City: "Berlin", IP: "1.2.3.4"},
+		}
+		third = &fakeProvider{
+			name:     "third",
+			location: &types.GeoIPLocation{City: "Paris", IP: "1.2.3.4"},
+		}
+	)
+
+	location, err := Location("", first, second, third)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.City != "Berlin" {
+		t.Fatalf("expected the location of the first working provider, got %s", location.City)
+	}
+	if first.calls != 1 || second.calls != 1 || third.calls != 0 {
+		t.Fatalf("unexpected calls %d, %d and %d", first.calls, second.calls, third.calls)
+	}
+}
+
+func TestLocation_WritesCache(t *testing.T) {
+	var (
+		path     = filepath.Join(t.TempDir(), "geoip.json")
+		provider = &fakeProvider{
+			name:     "fake",
+			location: &types.GeoIPLocation{City: "Berlin", Country: "Germany", IP: "1.2.3.4"},
+		}
+	)
+
+	if _, err := Location(path, provider); err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := readCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *location != *provider.location {
+		t.Fatalf("expected the cache to hold %v, got %v", provider.location, location)
+	}
+}
+
+func TestLocation_CacheFallback(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "geoip.json")
+		cached = &types.GeoIPLocation{City: "Berlin", IP: "1.2.3.4"}
+		failed = &fakeProvider{name: "fake", err: errors.New("unavailable")}
+	)
+
+	if err := writeCache(path, cached); err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := Location(path, failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *location != *cached {
+		t.Fatalf("expected the cached location %v, got %v", cached, location)
+	}
+}
+
+func TestLocation_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		path     string
+		contains []string
+	}{
+		{
+			name:     "without cache",
+			path:     "",
+			contains: []string{"first: unavailable", "second: unreachable"},
+		},
+		{
+			name:     "missing cache",
+			path:     filepath.Join(dir, "missing.json"),
+			contains: []string{"first: unavailable", "second: unreachable"},
+		},
+		{
+			name:     "invalid cache",
+			path:     filepath.Join(dir, "invalid.json"),
+			contains: []string{"first: unavailable", "cache:"},
+		},
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Location(
+				tt.path,
+				&fakeProvider{name: "first", err: errors.New("unavailable")},
+				&fakeProvider{name: "second", err: errors.New("unreachable")},
+			)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			for _, s := range tt.contains {
+				if !strings.Contains(err.Error(), s) {
+					t.Fatalf("expected the error %q to contain %q", err, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package geoip
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/libs/geoip/types"
+)
+
+var (
+	_ Provider = (*IPAPIProvider)(nil)
+)
+
+type IPAPIProvider struct {
+	client *http.Client
+}
+
+func NewIPAPIProvider(timeout time.Duration) *IPAPIProvider {
+	return &IPAPIProvider{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *IPAPIProvider) Name() string {
+	return "ip-api"
+}
+
+func (p *IPAPIProvider) Location() (*types.GeoIPLocation, error) {
+	var body struct {
+		City      string  `json:"city"`
+		Country   string  `json:"country"`
+		IP        string  `json:"query"`
+		Latitude  float64 `json:"lat"`
+		Longitude float64 `json:"lon"`
+		Message   string  `json:"message"`
+		Status    string  `json:"status"`
+	}
+
+	if err := getJSON(p.client, "http://ip-api.com/json", &body); err != nil {
+		return nil, err
+	}
+	if body.Status == "fail" {
+		return nil, errors.New(body.Message)
+	}
+
+	return &types.GeoIPLocation{
+		City:      body.City,
+		Country:   body.Country,
+		IP:        body.IP,
+		Latitude:  body.Latitude,
+		Longitude: body.Longitude,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package geoip
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/libs/geoip/types"
+)
+
+var (
+	_ Provider = (*IPInfoProvider)(nil)
+)
+
+type IPInfoProvider struct {
+	client *http.Client
+	token  string
+}
+
+func NewIPInfoProvider(token string, timeout time.Duration) *IPInfoProvider {
+	return &IPInfoProvider{
+		client: &http.Client{Timeout: timeout},
+		token:  token,
+	}
+}
+
+func (p *IPInfoProvider) Name() string {
+	return "ipinfo"
+}
+
+func (p *IPInfoProvider) Location() (*types.GeoIPLocation, error) {
+	path := "https://ipinfo.io/json"
+	if p.token != "" {
+		path += "?token=" + url.QueryEscape(p.token)
+	}
+
+	var body struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+		IP      string `json:"ip"`
+		Loc     string `json:"loc"`
+	}
+
+	if err := getJSON(p.client, path, &body); err != nil {
+		return nil, err
+	}
+
+	coordinates := strings.Split(body.Loc, ",")
+	if len(coordinates) != 2 {
+		return nil, fmt.Errorf("invalid loc %s", body.Loc)
+	}
+
+	latitude, err := strconv.ParseFloat(coordinates[0], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	longitude, err := strconv.ParseFloat(coordinates[1], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.GeoIPLocation{
+		City:      body.City,
+		Country:   body.Country,
+		IP:        body.IP,
+		Latitude:  latitude,
+		Longitude: longitude,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/sentinel-official/dvpn-node/libs/geoip/types"
+)
+
+var (
+	_ Provider = (*MaxMindProvider)(nil)
+)
+
+type maxMindRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// MaxMindProvider looks up the public address of the node in a local
+// GeoLite2 or GeoIP2 City database, so it works without network access.
+type MaxMindProvider struct {
+	path string
+	ip   string
+}
+
+func NewMaxMindProvider(path, ip string) *MaxMindProvider {
+	return &MaxMindProvider{
+		path: path,
+		ip:   ip,
+	}
+}
+
+func (p *MaxMindProvider) Name() string {
+	return "maxmind"
+}
+
+func (p *MaxMindProvider) Location() (*types.GeoIPLocation, error) {
+	if p.path == "" {
+		return nil, errors.New("database path cannot be empty")
+	}
+
+	ip := net.ParseIP(p.ip)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip address %q", p.ip)
+	}
+
+	r, err := maxminddb.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = r.Close()
+	}()
+
+	var record maxMindRecord
+	_, ok, err := r.LookupNetwork(ip, &record)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("ip address %s does not exist in the database", ip)
+	}
+
+	return &types.GeoIPLocation{
+		City:      record.City.Names["en"],
+		Country:   record.Country.Names["en"],
+		IP:        ip.String(),
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+	}, nil
+}
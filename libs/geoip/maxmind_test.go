@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+func writeTestMMDB(t *testing.T) string {
+	t.Helper()
+
+	w, err := mmdbwriter.New(
+		mmdbwriter.Options{
+			DatabaseType: "GeoLite2-City",
+			RecordSize:   24,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, network, err := net.ParseCIDR("1.2.3.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Insert(network, mmdbtype.Map{
+		"city": mmdbtype.Map{
+			"names": mmdbtype.Map{"en": mmdbtype.String("Berlin")},
+		},
+		"country": mmdbtype.Map{
+			"names": mmdbtype.Map{"en": mmdbtype.String("Germany")},
+		},
+		"location": mmdbtype.Map{
+			"latitude":  mmdbtype.Float64(52.52),
+			"longitude": mmdbtype.Float64(13.405),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err = f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if _, err = w.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestMaxMindProvider_Location(t *testing.T) {
+	path := writeTestMMDB(t)
+
+	location, err := NewMaxMindProvider(path, "1.2.3.4").Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.City != "Berlin" || location.Country != "Germany" || location.IP != "1.2.3.4" {
+		t.Fatalf("unexpected location %v", location)
+	}
+	if location.Latitude != 52.52 || location.Longitude != 13.405 {
+		t.Fatalf("unexpected coordinates %v and %v", location.Latitude, location.Longitude)
+	}
+}
+
+func TestMaxMindProvider_Errors(t *testing.T) {
+	var (
+		path    = writeTestMMDB(t)
+		invalid = filepath.Join(t.TempDir(), "invalid.mmdb")
+	)
+
+	if err := os.WriteFile(invalid, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		ip   string
+	}{
+		{name: "empty path", path: "", ip: "1.2.3.4"},
+		{name: "invalid ip", path: path, ip: "invalid"},
+		{name: "missing database", path: filepath.Join(t.TempDir(), "missing.mmdb"), ip: "1.2.3.4"},
+		{name: "invalid database", path: invalid, ip: "1.2.3.4"},
+		{name: "unknown ip", path: path, ip: "5.6.7.8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMaxMindProvider(tt.path, tt.ip).Location(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
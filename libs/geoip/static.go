@@ -0,0 +1,34 @@
+package geoip
+
+import (
+	"errors"
+
+	"github.com/sentinel-official/dvpn-node/libs/geoip/types"
+)
+
+var (
+	_ Provider = (*StaticProvider)(nil)
+)
+
+type StaticProvider struct {
+	location types.GeoIPLocation
+}
+
+func NewStaticProvider(location types.GeoIPLocation) *StaticProvider {
+	return &StaticProvider{
+		location: location,
+	}
+}
+
+func (p *StaticProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticProvider) Location() (*types.GeoIPLocation, error) {
+	if p.location.IP == "" {
+		return nil, errors.New("ip address cannot be empty")
+	}
+
+	location := p.location
+	return &location, nil
+}
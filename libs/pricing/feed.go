@@ -0,0 +1,61 @@
+package pricing
+
+import (
+	"encoding/json"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/sentinel-official/dvpn-node/libs/pricing/types"
+)
+
+var (
+	_ Rule = (*FeedRule)(nil)
+)
+
+// FeedRule replaces the base prices with the ones of a JSON file, which is
+// read on every evaluation so that an external process can update it.
+type FeedRule struct {
+	path string
+}
+
+func NewFeedRule(path string) *FeedRule {
+	return &FeedRule{
+		path: path,
+	}
+}
+
+func (r *FeedRule) Name() string {
+	return "feed"
+}
+
+func (r *FeedRule) Apply(_ *types.Input, _ *types.Prices) (*types.Prices, error) {
+	buf, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var v struct {
+		GigabytePrices string `json:"gigabyte_prices"`
+		HourlyPrices   string `json:"hourly_prices"`
+	}
+
+	if err = json.Unmarshal(buf, &v); err != nil {
+		return nil, err
+	}
+
+	gigabytePrices, err := sdk.ParseCoinsNormalized(v.GigabytePrices)
+	if err != nil {
+		return nil, err
+	}
+
+	hourlyPrices, err := sdk.ParseCoinsNormalized(v.HourlyPrices)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Prices{
+		Gigabyte: gigabytePrices,
+		Hourly:   hourlyPrices,
+	}, nil
+}
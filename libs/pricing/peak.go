@@ -0,0 +1,47 @@
+package pricing
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/sentinel-official/dvpn-node/libs/pricing/types"
+)
+
+var (
+	_ Rule = (*PeakRule)(nil)
+)
+
+// PeakRule multiplies the prices within the daily hours from start to end in
+// UTC, which wrap around midnight when the end is before the start.
+type PeakRule struct {
+	start      int
+	end        int
+	multiplier sdk.Dec
+}
+
+func NewPeakRule(start, end int, multiplier sdk.Dec) *PeakRule {
+	return &PeakRule{
+		start:      start,
+		end:        end,
+		multiplier: multiplier,
+	}
+}
+
+func (r *PeakRule) Name() string {
+	return "peak"
+}
+
+func (r *PeakRule) isPeak(hour int) bool {
+	if r.start <= r.end {
+		return hour >= r.start && hour < r.end
+	}
+
+	return hour >= r.start || hour < r.end
+}
+
+func (r *PeakRule) Apply(input *types.Input, prices *types.Prices) (*types.Prices, error) {
+	if !r.isPeak(input.Time.UTC().Hour()) {
+		return prices, nil
+	}
+
+	return prices.Mul(r.multiplier), nil
+}
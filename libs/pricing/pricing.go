@@ -0,0 +1,27 @@
+package pricing
+
+import (
+	"fmt"
+
+	"github.com/sentinel-official/dvpn-node/libs/pricing/types"
+)
+
+type Rule interface {
+	Name() string
+	Apply(input *types.Input, prices *types.Prices) (*types.Prices, error)
+}
+
+// Evaluate applies the rules in order to the base prices.
+func Evaluate(input *types.Input, base *types.Prices, rules ...Rule) (*types.Prices, error) {
+	prices := base
+	for _, rule := range rules {
+		result, err := rule.Apply(input, prices)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply the rule %s; %w", rule.Name(), err)
+		}
+
+		prices = result
+	}
+
+	return prices, nil
+}
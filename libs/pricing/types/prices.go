@@ -0,0 +1,70 @@
+package types
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Input is the state of the node the prices are evaluated against.
+type Input struct {
+	Time     time.Time
+	Peers    int
+	MaxPeers int
+}
+
+type Prices struct {
+	Gigabyte sdk.Coins `json:"gigabyte_prices"`
+	Hourly   sdk.Coins `json:"hourly_prices"`
+}
+
+// Mul returns the prices multiplied by the factor, truncating the amounts.
+func (p *Prices) Mul(v sdk.Dec) *Prices {
+	mul := func(coins sdk.Coins) sdk.Coins {
+		result := sdk.NewCoins()
+		for _, coin := range coins {
+			amount := sdk.NewDecFromInt(coin.Amount).Mul(v).TruncateInt()
+			result = result.Add(sdk.NewCoin(coin.Denom, amount))
+		}
+
+		return result
+	}
+
+	return &Prices{
+		Gigabyte: mul(p.Gigabyte),
+		Hourly:   mul(p.Hourly),
+	}
+}
+
+// Change returns the largest relative change of any price from the other
+// prices, where a price added or removed counts as a full change.
+func (p *Prices) Change(other *Prices) sdk.Dec {
+	change := func(x, y sdk.Coins) sdk.Dec {
+		result := sdk.ZeroDec()
+		for _, coins := range []sdk.Coins{x, y} {
+			for _, coin := range coins {
+				var (
+					a = x.AmountOf(coin.Denom)
+					b = y.AmountOf(coin.Denom)
+				)
+
+				v := sdk.OneDec()
+				if b.IsPositive() {
+					v = sdk.NewDecFromInt(a.Sub(b).Abs()).QuoInt(b)
+				}
+				if a.Equal(b) {
+					v = sdk.ZeroDec()
+				}
+
+				result = sdk.MaxDec(result, v)
+			}
+		}
+
+		return result
+	}
+
+	return sdk.MaxDec(
+		change(p.Gigabyte, other.Gigabyte),
+		change(p.Hourly, other.Hourly),
+	)
+}
@@ -0,0 +1,41 @@
+package pricing
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/sentinel-official/dvpn-node/libs/pricing/types"
+)
+
+var (
+	_ Rule = (*UtilisationRule)(nil)
+)
+
+// UtilisationRule scales the prices linearly with the peer count, from the
+// prices at no peers up to the multiplier at the maximum peers.
+type UtilisationRule struct {
+	multiplier sdk.Dec
+}
+
+func NewUtilisationRule(multiplier sdk.Dec) *UtilisationRule {
+	return &UtilisationRule{
+		multiplier: multiplier,
+	}
+}
+
+func (r *UtilisationRule) Name() string {
+	return "utilisation"
+}
+
+func (r *UtilisationRule) Apply(input *types.Input, prices *types.Prices) (*types.Prices, error) {
+	if input.MaxPeers <= 0 {
+		return prices, nil
+	}
+
+	peers := input.Peers
+	if peers > input.MaxPeers {
+		peers = input.MaxPeers
+	}
+
+	v := r.multiplier.Sub(sdk.OneDec()).MulInt64(int64(peers)).QuoInt64(int64(input.MaxPeers))
+	return prices.Mul(sdk.OneDec().Add(v)), nil
+}
@@ -0,0 +1,116 @@
+package lite
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sentinel-official/dvpn-node/metrics"
+)
+
+type cacheEntry struct {
+	value  interface{}
+	expiry time.Time
+}
+
+// QueryCache keeps the results of the chain queries for a while. Accounts with
+// a public key and the plan membership rarely change, so they are kept for
+// longer than the nodes, which are only kept while active.
+type QueryCache struct {
+	mutex      sync.Mutex
+	now        func() time.Time
+	items      map[string]cacheEntry
+	maxEntries int
+	ttls       map[int]time.Duration
+}
+
+const (
+	cacheNone = iota
+	cacheAccount
+	cachePlan
+	cacheStatus
+)
+
+func NewQueryCache(maxEntries int, accountTTL, planTTL, statusTTL time.Duration) *QueryCache {
+	return &QueryCache{
+		now:        time.Now,
+		items:      make(map[string]cacheEntry),
+		maxEntries: maxEntries,
+		ttls: map[int]time.Duration{
+			cacheAccount: accountTTL,
+			cachePlan:    planTTL,
+			cacheStatus:  statusTTL,
+		},
+	}
+}
+
+func (c *QueryCache) get(query, key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key = query + "/" + key
+
+	item, ok := c.items[key]
+	if ok && c.now().After(item.expiry) {
+		delete(c.items, key)
+		ok = false
+	}
+
+	if ok {
+		metrics.ChainCacheRequests.WithLabelValues(query, "hit").Inc()
+		return item.value, true
+	}
+
+	metrics.ChainCacheRequests.WithLabelValues(query, "miss").Inc()
+	return nil, false
+}
+
+func (c *QueryCache) set(query, key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.items) >= c.maxEntries {
+		now := c.now()
+		for k, v := range c.items {
+			if now.After(v.expiry) {
+				delete(c.items, k)
+			}
+		}
+	}
+	if len(c.items) >= c.maxEntries {
+		// Map iteration order is random, so this evicts an arbitrary entry
+		for k := range c.items {
+			delete(c.items, k)
+			break
+		}
+	}
+
+	c.items[query+"/"+key] = cacheEntry{
+		value:  value,
+		expiry: c.now().Add(ttl),
+	}
+}
+
+// cached returns the result of the query from the cache of the client, or runs
+// the query and caches its result for the duration of the returned class.
+func cached[T any](c *Client, query, key string, fn func() (T, int, error)) (T, error) {
+	if c.cache == nil {
+		v, _, err := fn()
+		return v, err
+	}
+
+	if v, ok := c.cache.get(query, key); ok {
+		return v.(T), nil
+	}
+
+	v, class, err := fn()
+	if err != nil {
+		return v, err
+	}
+
+	c.cache.set(query, key, v, c.cache.ttls[class])
+	return v, nil
+}
@@ -3,6 +3,7 @@ package lite
 import (
 	"io"
 	"sync"
+	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
@@ -12,21 +13,33 @@ import (
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	tmlog "github.com/tendermint/tendermint/libs/log"
+	"google.golang.org/grpc"
 )
 
 type Client struct {
-	ctx          client.Context
-	log          tmlog.Logger
-	mutex        *sync.Mutex
-	queryTimeout uint
-	remotes      []string
-	txf          tx.Factory
-	txTimeout    uint
+	authzGranter   sdk.AccAddress
+	cache          *QueryCache
+	confirmTimeout uint
+	ctx            client.Context
+	grpcConns      map[string]*grpc.ClientConn
+	grpcMutex      *sync.Mutex
+	health         *health
+	log            tmlog.Logger
+	mutex          *sync.Mutex
+	queryTimeout   uint
+	remotes        []string
+	sequence       *sequence
+	txf            tx.Factory
+	txTimeout      uint
 }
 
 func NewClient() *Client {
 	return &Client{
-		mutex: &sync.Mutex{},
+		grpcConns: make(map[string]*grpc.ClientConn),
+		grpcMutex: &sync.Mutex{},
+		health:    newHealth(time.Now),
+		mutex:     &sync.Mutex{},
+		sequence:  &sequence{},
 	}
 }
 
@@ -34,7 +47,7 @@ func NewDefaultClient() *Client {
 	var (
 		cfg = DefaultEncodingConfig()
 		ctx = client.Context{}.
-			WithBroadcastMode(flags.BroadcastBlock).
+			WithBroadcastMode(flags.BroadcastSync).
 			WithCodec(cfg.Codec).
 			WithInterfaceRegistry(cfg.InterfaceRegistry).
 			WithLegacyAmino(cfg.Amino).
@@ -49,11 +62,42 @@ func NewDefaultClient() *Client {
 		WithTxConfig(cfg.TxConfig)
 }
 
+func (c *Client) WithQueryCache(v *QueryCache) *Client {
+	c.cache = v
+	return c
+}
+
+// WithAuthzGranter makes the client execute the messages on behalf of the
+// granter through an authz MsgExec signed by the from key.
+func (c *Client) WithAuthzGranter(v sdk.AccAddress) *Client {
+	c.authzGranter = v
+	return c
+}
+
+func (c *Client) WithBroadcastMode(v string) *Client {
+	c.ctx = c.ctx.WithBroadcastMode(v)
+	return c
+}
+
+// WithConfirmTimeout sets the seconds to wait for the inclusion of the
+// transactions broadcast in sync or async mode; 0 does not wait.
+func (c *Client) WithConfirmTimeout(v uint) *Client {
+	c.confirmTimeout = v
+	return c
+}
+
 func (c *Client) WithContext(v client.Context) *Client {
 	c.ctx = v
 	return c
 }
 
+// WithClock sets the source of the current time used for the circuit
+// breaking of the remotes.
+func (c *Client) WithClock(v func() time.Time) *Client {
+	c.health.now = v
+	return c
+}
+
 func (c *Client) WithLogger(v tmlog.Logger) *Client {
 	c.log = v
 	return c
@@ -147,7 +191,9 @@ func (c *Client) WithTxConfig(v client.TxConfig) *Client {
 	return c
 }
 
-func (c *Client) FromAddress() sdk.AccAddress { return c.ctx.FromAddress }
-func (c *Client) FromName() string            { return c.ctx.FromName }
-func (c *Client) SimulateAndExecute() bool    { return c.txf.SimulateAndExecute() }
-func (c *Client) TxConfig() client.TxConfig   { return c.ctx.TxConfig }
+func (c *Client) AuthzGranter() sdk.AccAddress { return c.authzGranter }
+func (c *Client) FeeGranter() sdk.AccAddress   { return c.ctx.FeeGranter }
+func (c *Client) FromAddress() sdk.AccAddress  { return c.ctx.FromAddress }
+func (c *Client) FromName() string             { return c.ctx.FromName }
+func (c *Client) SimulateAndExecute() bool     { return c.txf.SimulateAndExecute() }
+func (c *Client) TxConfig() client.TxConfig    { return c.ctx.TxConfig }
@@ -9,6 +9,8 @@ import (
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
 	authvesting "github.com/cosmos/cosmos-sdk/x/auth/vesting"
+	authzmodule "github.com/cosmos/cosmos-sdk/x/authz/module"
+	feegrantmodule "github.com/cosmos/cosmos-sdk/x/feegrant/module"
 	"github.com/sentinel-official/hub/x/vpn"
 )
 
@@ -41,6 +43,8 @@ func DefaultEncodingConfig() EncodingConfig {
 		modules = module.NewBasicManager(
 			auth.AppModuleBasic{},
 			authvesting.AppModuleBasic{},
+			authzmodule.AppModuleBasic{},
+			feegrantmodule.AppModuleBasic{},
 			vpn.AppModuleBasic{},
 		)
 	)
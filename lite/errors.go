@@ -0,0 +1,113 @@
+package lite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TxError is returned when the chain rejects a broadcast transaction.
+type TxError struct {
+	Code      uint32
+	Codespace string
+	Log       string
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("codespace %s, code %d: %s", e.Codespace, e.Code, e.Log)
+}
+
+// UnknownTxError is returned when none of the remotes answered the broadcast of
+// a transaction in time, so it may have reached a mempool all the same. The
+// transaction is not broadcast again; its hash is polled instead, and the
+// sequence is synced from the chain before signing the next one.
+type UnknownTxError struct {
+	TxHash string
+	Err    error
+}
+
+func (e *UnknownTxError) Error() string {
+	return fmt.Sprintf("outcome of transaction %s is unknown: %s", e.TxHash, e.Err)
+}
+
+func (e *UnknownTxError) Unwrap() error {
+	return e.Err
+}
+
+// IsUnknownTxError reports whether the outcome of the broadcast is unknown.
+func IsUnknownTxError(err error) bool {
+	var txErr *UnknownTxError
+	return errors.As(err, &txErr)
+}
+
+// IsChainError reports whether the error was returned by the chain while
+// checking the messages, as opposed to a failure to reach the remotes.
+func IsChainError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var txErr *TxError
+	if errors.As(err, &txErr) {
+		return true
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch s.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return false
+	default:
+		return true
+	}
+}
+
+// IsSequenceError reports whether the chain rejected the transaction
+// because of an account sequence mismatch.
+func IsSequenceError(err error) bool {
+	var txErr *TxError
+	if errors.As(err, &txErr) {
+		return txErr.Codespace == sdkerrors.ErrWrongSequence.Codespace() &&
+			txErr.Code == sdkerrors.ErrWrongSequence.ABCICode()
+	}
+
+	// The simulations return the error of the ante handler as a status
+	if s, ok := status.FromError(err); ok {
+		return strings.Contains(s.Message(), "account sequence mismatch")
+	}
+
+	return false
+}
+
+func isRetryableTxError(err error) bool {
+	if IsUnknownTxError(err) {
+		return false
+	}
+
+	return !IsChainError(err) || IsSequenceError(err)
+}
+
+// isTimeoutError reports whether a remote did not answer in time, as opposed to
+// refusing the request.
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.DeadlineExceeded
+}
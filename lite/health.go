@@ -0,0 +1,263 @@
+package lite
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+
+	"github.com/sentinel-official/dvpn-node/metrics"
+)
+
+const (
+	healthSmoothing  = 0.2
+	breakerThreshold = 3
+	breakerCooldown  = 30 * time.Second
+	maxHeightLag     = 10
+)
+
+// RemoteHealth is the health of a remote derived from the requests made to
+// it and from the periodic checks of its latest block height.
+type RemoteHealth struct {
+	Remote    string        `json:"remote"`
+	Latency   time.Duration `json:"latency"`
+	ErrorRate float64       `json:"error_rate"`
+	Height    int64         `json:"height"`
+	Lag       int64         `json:"lag"`
+	Failures  int           `json:"failures"`
+	OpenUntil time.Time     `json:"open_until,omitempty"`
+}
+
+// IsOpen reports whether the circuit of the remote is open, in which case
+// the remote is only tried after all the others.
+func (h *RemoteHealth) IsOpen(now time.Time) bool {
+	return now.Before(h.OpenUntil)
+}
+
+// Score returns the cost of using the remote; lower is better.
+func (h *RemoteHealth) Score() float64 {
+	score := float64(h.Latency.Milliseconds()+1) * (1 + 10*h.ErrorRate)
+	if h.Lag > maxHeightLag {
+		score *= float64(h.Lag)
+	}
+
+	return score
+}
+
+type health struct {
+	mutex  sync.RWMutex
+	now    func() time.Time
+	active string
+	items  map[string]*RemoteHealth
+}
+
+func newHealth(now func() time.Time) *health {
+	return &health{
+		now:   now,
+		items: make(map[string]*RemoteHealth),
+	}
+}
+
+func (h *health) get(remote string) *RemoteHealth {
+	item, ok := h.items[remote]
+	if !ok {
+		item = &RemoteHealth{Remote: remote}
+		h.items[remote] = item
+	}
+
+	return item
+}
+
+func (h *health) observe(remote string, latency time.Duration, failed bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	item := h.get(remote)
+	if item.Latency == 0 {
+		item.Latency = latency
+	} else {
+		item.Latency += time.Duration(healthSmoothing * float64(latency-item.Latency))
+	}
+
+	if !failed {
+		item.ErrorRate -= healthSmoothing * item.ErrorRate
+		item.Failures, item.OpenUntil = 0, time.Time{}
+		return
+	}
+
+	item.ErrorRate += healthSmoothing * (1 - item.ErrorRate)
+	item.Failures++
+	if item.Failures >= breakerThreshold {
+		item.OpenUntil = h.now().Add(breakerCooldown)
+	}
+}
+
+func (h *health) setHeights(heights map[string]int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var max int64
+	for _, height := range heights {
+		if height > max {
+			max = height
+		}
+	}
+
+	for remote, height := range heights {
+		item := h.get(remote)
+		item.Height, item.Lag = height, max-height
+	}
+}
+
+// order returns the remotes sorted by their score, with the remotes having an
+// open circuit moved to the end.
+func (h *health) order(remotes []string) []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	var (
+		now   = h.now()
+		items = append([]string(nil), remotes...)
+	)
+
+	key := func(remote string) (bool, float64) {
+		item, ok := h.items[remote]
+		if !ok {
+			return false, 0
+		}
+
+		return item.IsOpen(now), item.Score()
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		openI, scoreI := key(items[i])
+		openJ, scoreJ := key(items[j])
+		if openI != openJ {
+			return !openI
+		}
+
+		return scoreI < scoreJ
+	})
+
+	return items
+}
+
+func (h *health) snapshot(remotes []string) []RemoteHealth {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	items := make([]RemoteHealth, 0, len(remotes))
+	for _, remote := range remotes {
+		if item, ok := h.items[remote]; ok {
+			items = append(items, *item)
+		} else {
+			items = append(items, RemoteHealth{Remote: remote})
+		}
+	}
+
+	return items
+}
+
+func (h *health) setActive(remote string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.active = remote
+}
+
+func (c *Client) observe(method, remote string, start time.Time, err error) {
+	metrics.ObserveChainRequest(method, remote, start, err)
+
+	failed := err != nil && !IsChainError(err)
+	c.health.observe(remote, time.Since(start), failed)
+	if !failed {
+		c.health.setActive(remote)
+	}
+}
+
+// Remotes returns the remotes in the order they are tried.
+func (c *Client) Remotes() []string {
+	return c.health.order(c.remotes)
+}
+
+// ActiveRemote returns the remote which served the latest successful request.
+func (c *Client) ActiveRemote() string {
+	c.health.mutex.RLock()
+	defer c.health.mutex.RUnlock()
+
+	return c.health.active
+}
+
+func (c *Client) RemoteHealth() []RemoteHealth {
+	return c.health.snapshot(c.remotes)
+}
+
+func (c *Client) queryHeight(ctx context.Context, remote string) (int64, error) {
+	transport, _, err := RemoteTransport(remote)
+	if err != nil {
+		return 0, err
+	}
+
+	if transport == TransportRPC {
+		client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+		if err != nil {
+			return 0, err
+		}
+
+		status, err := client.Status(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		return status.SyncInfo.LatestBlockHeight, nil
+	}
+
+	conn, err := c.conn(remote, c.queryTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tmservice.NewServiceClient(conn).GetLatestBlock(ctx, &tmservice.GetLatestBlockRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.Block.Header.Height, nil
+}
+
+// CheckRemotes queries the latest block height of all the remotes to update
+// their latency and their lag behind the highest one.
+func (c *Client) CheckRemotes(ctx context.Context) {
+	var (
+		mutex   sync.Mutex
+		wg      sync.WaitGroup
+		heights = make(map[string]int64)
+	)
+
+	for _, remote := range c.remotes {
+		wg.Add(1)
+		go func(remote string) {
+			defer wg.Done()
+
+			start := time.Now()
+			height, err := c.queryHeight(ctx, remote)
+			metrics.ObserveChainRequest("status", remote, start, err)
+			c.health.observe(remote, time.Since(start), err != nil)
+			if err != nil {
+				c.log.Debug("failed to check the remote", "remote", remote, "error", err)
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			heights[remote] = height
+		}(remote)
+	}
+
+	wg.Wait()
+	c.health.setHeights(heights)
+}
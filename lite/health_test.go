@@ -0,0 +1,212 @@
+package lite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// fakeRPCServer is an in-process Tendermint RPC server answering the status
+// requests with its height, or with an error while it is failing.
+type fakeRPCServer struct {
+	*httptest.Server
+
+	height  atomic.Int64
+	failing atomic.Bool
+}
+
+func newFakeRPCServer(t *testing.T, height int64) *fakeRPCServer {
+	t.Helper()
+
+	s := &fakeRPCServer{}
+	s.height.Store(height)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"sync_info":{"latest_block_height":"%d"}}}`,
+			req.ID, s.height.Load())
+	}))
+
+	t.Cleanup(s.Close)
+	return s
+}
+
+// fakeClock is a source of the current time which only moves when advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestHealthClient(clock *fakeClock, remotes ...string) *Client {
+	return NewClient().
+		WithClock(clock.Now).
+		WithLogger(tmlog.NewNopLogger()).
+		WithQueryTimeout(5).
+		WithRemotes(remotes)
+}
+
+func findRemoteHealth(t *testing.T, c *Client, remote string) RemoteHealth {
+	t.Helper()
+
+	for _, item := range c.RemoteHealth() {
+		if item.Remote == remote {
+			return item
+		}
+	}
+
+	t.Fatalf("no health of remote %s", remote)
+	return RemoteHealth{}
+}
+
+func TestRemoteHealth_Score(t *testing.T) {
+	tests := []struct {
+		name string
+		item RemoteHealth
+		want float64
+	}{
+		{
+			name: "healthy",
+			item: RemoteHealth{Latency: 9 * time.Millisecond},
+			want: 10,
+		},
+		{
+			name: "erroring",
+			item: RemoteHealth{Latency: 9 * time.Millisecond, ErrorRate: 0.5},
+			want: 60,
+		},
+		{
+			name: "lag within the limit",
+			item: RemoteHealth{Latency: 9 * time.Millisecond, Lag: maxHeightLag},
+			want: 10,
+		},
+		{
+			name: "lag beyond the limit",
+			item: RemoteHealth{Latency: 9 * time.Millisecond, Lag: 2 * maxHeightLag},
+			want: 10 * 2 * maxHeightLag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.Score(); got != tt.want {
+				t.Fatalf("expected score %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_CheckRemotesHeights(t *testing.T) {
+	var (
+		clock   = &fakeClock{now: time.Unix(1700000000, 0)}
+		ahead   = newFakeRPCServer(t, 120)
+		behind  = newFakeRPCServer(t, 100)
+		client  = newTestHealthClient(clock, behind.URL, ahead.URL)
+		wantLag = map[string]int64{ahead.URL: 0, behind.URL: 20}
+	)
+
+	client.CheckRemotes(context.Background())
+
+	for remote, lag := range wantLag {
+		item := findRemoteHealth(t, client, remote)
+		if item.Lag != lag {
+			t.Fatalf("expected lag %d of remote %s, got %d", lag, remote, item.Lag)
+		}
+		if item.Latency <= 0 || item.ErrorRate != 0 || item.Failures != 0 {
+			t.Fatalf("unexpected health of remote %s: %+v", remote, item)
+		}
+	}
+}
+
+func TestClient_CheckRemotesErrorRate(t *testing.T) {
+	var (
+		clock  = &fakeClock{now: time.Unix(1700000000, 0)}
+		server = newFakeRPCServer(t, 100)
+		client = newTestHealthClient(clock, server.URL)
+	)
+
+	// Each check moves the error rate by the smoothing factor towards 1 on a
+	// failure and towards 0 on a success.
+	steps := []struct {
+		failing bool
+		want    float64
+	}{
+		{failing: true, want: 0.2},
+		{failing: true, want: 0.36},
+		{failing: false, want: 0.288},
+		{failing: false, want: 0.2304},
+	}
+
+	for i, step := range steps {
+		server.failing.Store(step.failing)
+		client.CheckRemotes(context.Background())
+
+		item := findRemoteHealth(t, client, server.URL)
+		if math.Abs(item.ErrorRate-step.want) > 1e-9 {
+			t.Fatalf("step %d: expected error rate %v, got %v", i, step.want, item.ErrorRate)
+		}
+	}
+}
+
+func TestClient_CheckRemotesBreaker(t *testing.T) {
+	var (
+		clock   = &fakeClock{now: time.Unix(1700000000, 0)}
+		broken  = newFakeRPCServer(t, 100)
+		healthy = newFakeRPCServer(t, 100)
+		client  = newTestHealthClient(clock, broken.URL, healthy.URL)
+	)
+
+	broken.failing.Store(true)
+	for i := 1; i < breakerThreshold; i++ {
+		client.CheckRemotes(context.Background())
+		if item := findRemoteHealth(t, client, broken.URL); item.IsOpen(clock.Now()) {
+			t.Fatalf("expected the circuit to be closed after %d failures", i)
+		}
+	}
+
+	client.CheckRemotes(context.Background())
+	item := findRemoteHealth(t, client, broken.URL)
+	if !item.IsOpen(clock.Now()) {
+		t.Fatalf("expected the circuit to be open after %d failures", breakerThreshold)
+	}
+	if want := clock.Now().Add(breakerCooldown); !item.OpenUntil.Equal(want) {
+		t.Fatalf("expected the circuit to be open until %s, got %s", want, item.OpenUntil)
+	}
+	if remotes := client.Remotes(); remotes[len(remotes)-1] != broken.URL {
+		t.Fatalf("expected the remote with the open circuit to be tried last, got %v", remotes)
+	}
+
+	clock.Advance(breakerCooldown)
+	if item = findRemoteHealth(t, client, broken.URL); item.IsOpen(clock.Now()) {
+		t.Fatal("expected the circuit to be closed after the cooldown")
+	}
+
+	broken.failing.Store(false)
+	client.CheckRemotes(context.Background())
+	item = findRemoteHealth(t, client, broken.URL)
+	if item.Failures != 0 || !item.OpenUntil.IsZero() {
+		t.Fatalf("expected a success to reset the circuit, got %+v", item)
+	}
+}
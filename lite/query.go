@@ -1,10 +1,13 @@
 package lite
 
 import (
-	"context"
+	"fmt"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	hubtypes "github.com/sentinel-official/hub/types"
 	nodetypes "github.com/sentinel-official/hub/x/node/types"
 	sessiontypes "github.com/sentinel-official/hub/x/session/types"
@@ -18,18 +21,17 @@ import (
 func (c *Client) queryAccount(remote string, accAddr sdk.AccAddress) (authtypes.AccountI, error) {
 	c.log.Debug("Querying the account", "remote", remote, "address", accAddr)
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+	conn, err := c.conn(remote, c.queryTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		ctx = c.ctx.WithClient(client)
-		qc  = authtypes.NewQueryClient(ctx)
-	)
+	ctx, cancel := c.queryContext()
+	defer cancel()
 
+	qc := authtypes.NewQueryClient(conn)
 	resp, err := qc.Account(
-		context.TODO(),
+		ctx,
 		&authtypes.QueryAccountRequest{
 			Address: accAddr.String(),
 		},
@@ -46,10 +48,54 @@ func (c *Client) queryAccount(remote string, accAddr sdk.AccAddress) (authtypes.
 	return result, nil
 }
 
-func (c *Client) QueryAccount(accAddr sdk.AccAddress) (result authtypes.AccountI, err error) {
+func (c *Client) queryAccountFromRemotes(accAddr sdk.AccAddress) (result authtypes.AccountI, err error) {
 	c.log.Info("Querying the account", "address", accAddr)
-	for i := 0; i < len(c.remotes); i++ {
-		result, err = c.queryAccount(c.remotes[i], accAddr)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.queryAccount(remotes[i], accAddr)
+		c.observe("query_account", remotes[i], start, err)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) queryBalances(remote string, accAddr sdk.AccAddress) (sdk.Coins, error) {
+	c.log.Debug("Querying the balances", "remote", remote, "address", accAddr)
+
+	conn, err := c.conn(remote, c.queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.queryContext()
+	defer cancel()
+
+	qc := banktypes.NewQueryClient(conn)
+	res, err := qc.AllBalances(
+		ctx,
+		banktypes.NewQueryAllBalancesRequest(accAddr, nil),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Balances, nil
+}
+
+func (c *Client) QueryBalances(accAddr sdk.AccAddress) (result sdk.Coins, err error) {
+	c.log.Info("Querying the balances", "address", accAddr)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.queryBalances(remotes[i], accAddr)
+		c.observe("query_balances", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -64,18 +110,17 @@ func (c *Client) QueryAccount(accAddr sdk.AccAddress) (result authtypes.AccountI
 func (c *Client) queryNode(remote string, nodeAddr hubtypes.NodeAddress) (*nodetypes.Node, error) {
 	c.log.Debug("Querying the node", "remote", remote, "address", nodeAddr)
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+	conn, err := c.conn(remote, c.queryTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		ctx = c.ctx.WithClient(client)
-		qc  = nodetypes.NewQueryServiceClient(ctx)
-	)
+	ctx, cancel := c.queryContext()
+	defer cancel()
 
+	qc := nodetypes.NewQueryServiceClient(conn)
 	res, err := qc.QueryNode(
-		context.TODO(),
+		ctx,
 		nodetypes.NewQueryNodeRequest(nodeAddr),
 	)
 	if err != nil {
@@ -85,10 +130,13 @@ func (c *Client) queryNode(remote string, nodeAddr hubtypes.NodeAddress) (*nodet
 	return &res.Node, nil
 }
 
-func (c *Client) QueryNode(nodeAddr hubtypes.NodeAddress) (result *nodetypes.Node, err error) {
+func (c *Client) queryNodeFromRemotes(nodeAddr hubtypes.NodeAddress) (result *nodetypes.Node, err error) {
 	c.log.Info("Querying the node", "address", nodeAddr)
-	for i := 0; i < len(c.remotes); i++ {
-		result, err = c.queryNode(c.remotes[i], nodeAddr)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.queryNode(remotes[i], nodeAddr)
+		c.observe("query_node", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -103,18 +151,17 @@ func (c *Client) QueryNode(nodeAddr hubtypes.NodeAddress) (result *nodetypes.Nod
 func (c *Client) querySubscription(remote string, id uint64) (subscriptiontypes.Subscription, error) {
 	c.log.Debug("Querying the subscription", "remote", remote, "id", id)
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+	conn, err := c.conn(remote, c.queryTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		ctx = c.ctx.WithClient(client)
-		qc  = subscriptiontypes.NewQueryServiceClient(ctx)
-	)
+	ctx, cancel := c.queryContext()
+	defer cancel()
 
+	qc := subscriptiontypes.NewQueryServiceClient(conn)
 	res, err := qc.QuerySubscription(
-		context.TODO(),
+		ctx,
 		subscriptiontypes.NewQuerySubscriptionRequest(id),
 	)
 	if err != nil {
@@ -129,10 +176,13 @@ func (c *Client) querySubscription(remote string, id uint64) (subscriptiontypes.
 	return result, nil
 }
 
-func (c *Client) QuerySubscription(id uint64) (result subscriptiontypes.Subscription, err error) {
+func (c *Client) querySubscriptionFromRemotes(id uint64) (result subscriptiontypes.Subscription, err error) {
 	c.log.Info("Querying the subscription", "id", id)
-	for i := 0; i < len(c.remotes); i++ {
-		result, err = c.querySubscription(c.remotes[i], id)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.querySubscription(remotes[i], id)
+		c.observe("query_subscription", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -147,18 +197,17 @@ func (c *Client) QuerySubscription(id uint64) (result subscriptiontypes.Subscrip
 func (c *Client) queryAllocation(remote string, id uint64, accAddr sdk.AccAddress) (*subscriptiontypes.Allocation, error) {
 	c.log.Debug("Querying the allocation", "remote", remote, "id", id, "address", accAddr)
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+	conn, err := c.conn(remote, c.queryTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		ctx = c.ctx.WithClient(client)
-		qc  = subscriptiontypes.NewQueryServiceClient(ctx)
-	)
+	ctx, cancel := c.queryContext()
+	defer cancel()
 
+	qc := subscriptiontypes.NewQueryServiceClient(conn)
 	res, err := qc.QueryAllocation(
-		context.TODO(),
+		ctx,
 		subscriptiontypes.NewQueryAllocationRequest(id, accAddr),
 	)
 	if err != nil {
@@ -168,10 +217,13 @@ func (c *Client) queryAllocation(remote string, id uint64, accAddr sdk.AccAddres
 	return &res.Allocation, nil
 }
 
-func (c *Client) QueryAllocation(id uint64, accAddr sdk.AccAddress) (result *subscriptiontypes.Allocation, err error) {
+func (c *Client) queryAllocationFromRemotes(id uint64, accAddr sdk.AccAddress) (result *subscriptiontypes.Allocation, err error) {
 	c.log.Info("Querying the allocation", "id", id, "address", accAddr)
-	for i := 0; i < len(c.remotes); i++ {
-		result, err = c.queryAllocation(c.remotes[i], id, accAddr)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.queryAllocation(remotes[i], id, accAddr)
+		c.observe("query_allocation", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -186,18 +238,17 @@ func (c *Client) QueryAllocation(id uint64, accAddr sdk.AccAddress) (result *sub
 func (c *Client) querySession(remote string, id uint64) (*sessiontypes.Session, error) {
 	c.log.Debug("Querying the session", "remote", remote, "id", id)
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+	conn, err := c.conn(remote, c.queryTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		ctx = c.ctx.WithClient(client)
-		qc  = sessiontypes.NewQueryServiceClient(ctx)
-	)
+	ctx, cancel := c.queryContext()
+	defer cancel()
 
+	qc := sessiontypes.NewQueryServiceClient(conn)
 	res, err := qc.QuerySession(
-		context.TODO(),
+		ctx,
 		sessiontypes.NewQuerySessionRequest(id),
 	)
 	if err != nil {
@@ -207,10 +258,13 @@ func (c *Client) querySession(remote string, id uint64) (*sessiontypes.Session,
 	return &res.Session, nil
 }
 
-func (c *Client) QuerySession(id uint64) (result *sessiontypes.Session, err error) {
+func (c *Client) querySessionFromRemotes(id uint64) (result *sessiontypes.Session, err error) {
 	c.log.Info("Querying the session", "id", id)
-	for i := 0; i < len(c.remotes); i++ {
-		result, err = c.querySession(c.remotes[i], id)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.querySession(remotes[i], id)
+		c.observe("query_session", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -223,6 +277,14 @@ func (c *Client) QuerySession(id uint64) (result *sessiontypes.Session, err erro
 }
 
 func (c *Client) hasNodeForPlan(remote string, id uint64, nodeAddr hubtypes.NodeAddress) (bool, error) {
+	transport, _, err := RemoteTransport(remote)
+	if err != nil {
+		return false, err
+	}
+	if transport != TransportRPC {
+		return c.hasNodeForPlanPaginated(remote, id, nodeAddr)
+	}
+
 	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
 	if err != nil {
 		return false, err
@@ -247,9 +309,51 @@ func (c *Client) hasNodeForPlan(remote string, id uint64, nodeAddr hubtypes.Node
 	return true, nil
 }
 
-func (c *Client) HasNodeForPlan(id uint64, nodeAddr hubtypes.NodeAddress) (result bool, err error) {
-	for i := 0; i < len(c.remotes); i++ {
-		result, err = c.hasNodeForPlan(c.remotes[i], id, nodeAddr)
+// hasNodeForPlanPaginated walks the nodes of the plan for the transports
+// which cannot query the store directly.
+func (c *Client) hasNodeForPlanPaginated(remote string, id uint64, nodeAddr hubtypes.NodeAddress) (bool, error) {
+	conn, err := c.conn(remote, c.queryTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		qc   = nodetypes.NewQueryServiceClient(conn)
+		page = &query.PageRequest{Limit: 100}
+	)
+
+	for {
+		ctx, cancel := c.queryContext()
+		res, err := qc.QueryNodesForPlan(
+			ctx,
+			nodetypes.NewQueryNodesForPlanRequest(id, hubtypes.StatusUnspecified, page),
+		)
+		cancel()
+
+		if err != nil {
+			return false, types.QueryError(err)
+		}
+
+		for _, item := range res.Nodes {
+			if item.Address == nodeAddr.String() {
+				return true, nil
+			}
+		}
+
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			return false, nil
+		}
+
+		page = &query.PageRequest{Key: res.Pagination.NextKey, Limit: 100}
+	}
+}
+
+func (c *Client) hasNodeForPlanFromRemotes(id uint64, nodeAddr hubtypes.NodeAddress) (result bool, err error) {
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		result, err = c.hasNodeForPlan(remotes[i], id, nodeAddr)
+		c.observe("has_node_for_plan", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -260,3 +364,55 @@ func (c *Client) HasNodeForPlan(id uint64, nodeAddr hubtypes.NodeAddress) (resul
 
 	return result, nil
 }
+
+func (c *Client) QueryAccount(accAddr sdk.AccAddress) (authtypes.AccountI, error) {
+	return cached(c, "account", accAddr.String(), func() (authtypes.AccountI, int, error) {
+		result, err := c.queryAccountFromRemotes(accAddr)
+		if result == nil || result.GetPubKey() == nil {
+			return result, cacheNone, err
+		}
+
+		return result, cacheAccount, err
+	})
+}
+
+func (c *Client) QueryNode(nodeAddr hubtypes.NodeAddress) (*nodetypes.Node, error) {
+	return cached(c, "node", nodeAddr.String(), func() (*nodetypes.Node, int, error) {
+		result, err := c.queryNodeFromRemotes(nodeAddr)
+		if result == nil || !result.Status.Equal(hubtypes.StatusActive) {
+			return result, cacheNone, err
+		}
+
+		return result, cacheStatus, err
+	})
+}
+
+// QuerySubscription is not cached, since the status of the subscription
+// decides whether its sessions are kept.
+func (c *Client) QuerySubscription(id uint64) (subscriptiontypes.Subscription, error) {
+	return c.querySubscriptionFromRemotes(id)
+}
+
+// QueryAllocation is not cached, since the utilised bytes of the allocation
+// change with every session update.
+func (c *Client) QueryAllocation(id uint64, accAddr sdk.AccAddress) (*subscriptiontypes.Allocation, error) {
+	return c.queryAllocationFromRemotes(id, accAddr)
+}
+
+// QuerySession is not cached, since the bandwidth and the status of the
+// session change on the chain.
+func (c *Client) QuerySession(id uint64) (*sessiontypes.Session, error) {
+	return c.querySessionFromRemotes(id)
+}
+
+func (c *Client) HasNodeForPlan(id uint64, nodeAddr hubtypes.NodeAddress) (bool, error) {
+	key := fmt.Sprintf("%d/%s", id, nodeAddr)
+	return cached(c, "has_node_for_plan", key, func() (bool, int, error) {
+		result, err := c.hasNodeForPlanFromRemotes(id, nodeAddr)
+		if !result {
+			return result, cacheStatus, err
+		}
+
+		return result, cachePlan, err
+	})
+}
@@ -0,0 +1,49 @@
+package lite
+
+import (
+	"fmt"
+	"sync"
+)
+
+// sequence tracks the account number and the next sequence of the signer
+// locally, so that the account is only queried again after a mismatch.
+type sequence struct {
+	mutex         sync.Mutex
+	accountNumber uint64
+	next          uint64
+	synced        bool
+}
+
+func (s *sequence) get(c *Client) (accountNumber, next uint64, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.synced {
+		acc, err := c.queryAccountFromRemotes(c.FromAddress())
+		if err != nil {
+			return 0, 0, err
+		}
+		if acc == nil {
+			return 0, 0, fmt.Errorf("account does not exist with address %s", c.FromAddress())
+		}
+
+		s.accountNumber, s.next, s.synced = acc.GetAccountNumber(), acc.GetSequence(), true
+		c.log.Debug("Synced the account sequence", "account_number", s.accountNumber, "sequence", s.next)
+	}
+
+	return s.accountNumber, s.next, nil
+}
+
+func (s *sequence) increment() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.next++
+}
+
+func (s *sequence) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.synced = false
+}
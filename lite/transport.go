@@ -0,0 +1,321 @@
+package lite
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	gogogrpc "github.com/gogo/protobuf/grpc"
+	"github.com/gogo/protobuf/proto"
+	hubtypes "github.com/sentinel-official/hub/types"
+	nodetypes "github.com/sentinel-official/hub/x/node/types"
+	sessiontypes "github.com/sentinel-official/hub/x/session/types"
+	subscriptiontypes "github.com/sentinel-official/hub/x/subscription/types"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	TransportRPC  = "rpc"
+	TransportGRPC = "grpc"
+	TransportLCD  = "lcd"
+)
+
+// RemoteTransport returns the transport of the remote selected by the scheme
+// of its URL, along with the address to reach it at. Tendermint RPC remotes
+// use http or https, gRPC remotes use grpc or grpcs (TLS), and LCD remotes
+// use lcd+http or lcd+https.
+func RemoteTransport(remote string) (transport, address string, err error) {
+	uri, err := url.Parse(remote)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch uri.Scheme {
+	case "http", "https":
+		return TransportRPC, remote, nil
+	case "grpc", "grpcs":
+		return TransportGRPC, uri.Host, nil
+	case "lcd+http", "lcd+https":
+		return TransportLCD, strings.TrimPrefix(remote, "lcd+"), nil
+	default:
+		return "", "", fmt.Errorf("unsupported remote scheme %s", uri.Scheme)
+	}
+}
+
+// conn returns a connection to the remote over its transport, on which the
+// query and the tx service clients can be created.
+func (c *Client) conn(remote string, timeout uint) (gogogrpc.ClientConn, error) {
+	transport, address, err := RemoteTransport(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	switch transport {
+	case TransportGRPC:
+		return c.grpcConn(remote, address)
+	case TransportLCD:
+		return &lcdConn{
+			address: address,
+			cdc:     c.ctx.Codec,
+			client: &http.Client{
+				Timeout: time.Duration(timeout) * time.Second,
+			},
+		}, nil
+	default:
+		client, err := rpchttp.NewWithTimeout(remote, "/websocket", timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.ctx.WithClient(client), nil
+	}
+}
+
+func (c *Client) grpcConn(remote, address string) (*grpc.ClientConn, error) {
+	c.grpcMutex.Lock()
+	defer c.grpcMutex.Unlock()
+
+	if conn, ok := c.grpcConns[remote]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if strings.HasPrefix(remote, "grpcs://") {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	conn, err := grpc.Dial(
+		address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(gogoCodec{})),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.grpcConns[remote] = conn
+	return conn, nil
+}
+
+// queryContext returns a context bounded by the query timeout.
+func (c *Client) queryContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(c.queryTimeout)*time.Second)
+}
+
+// txContext returns a context bounded by the transaction timeout.
+func (c *Client) txContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(c.txTimeout)*time.Second)
+}
+
+// gogoCodec marshals the gogo protobuf messages of the SDK and the hub, which
+// the default codec of gRPC does not support.
+type gogoCodec struct{}
+
+func (gogoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal type %T", v)
+	}
+
+	return m.Marshal()
+}
+
+func (gogoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Unmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal type %T", v)
+	}
+
+	return m.Unmarshal(data)
+}
+
+func (gogoCodec) Name() string {
+	return "proto"
+}
+
+// lcdConn serves the gRPC methods used by the client through the REST
+// gateway of a node.
+type lcdConn struct {
+	address string
+	cdc     codec.Codec
+	client  *http.Client
+}
+
+var (
+	_ gogogrpc.ClientConn = (*lcdConn)(nil)
+)
+
+func lcdPagination(values url.Values, v *query.PageRequest) {
+	if v == nil {
+		return
+	}
+	if len(v.Key) > 0 {
+		values.Set("pagination.key", base64.StdEncoding.EncodeToString(v.Key))
+	}
+	if v.Limit > 0 {
+		values.Set("pagination.limit", fmt.Sprintf("%d", v.Limit))
+	}
+}
+
+func (c *lcdConn) route(method string, args interface{}) (string, string, error) {
+	values := url.Values{}
+
+	switch req := args.(type) {
+	case *authtypes.QueryAccountRequest:
+		return http.MethodGet, "/cosmos/auth/v1beta1/accounts/" + req.Address, nil
+	case *banktypes.QueryAllBalancesRequest:
+		lcdPagination(values, req.Pagination)
+		return http.MethodGet, fmt.Sprintf("/cosmos/bank/v1beta1/balances/%s?%s", req.Address, values.Encode()), nil
+	case *nodetypes.QueryNodeRequest:
+		return http.MethodGet, "/sentinel/nodes/" + req.Address, nil
+	case *nodetypes.QueryNodesForPlanRequest:
+		if !req.Status.Equal(hubtypes.StatusUnspecified) {
+			// The gateway parses the names of the enum values, which are
+			// not what the String method of the status returns
+			values.Set("status", hubtypes.Status_name[int32(req.Status)])
+		}
+
+		lcdPagination(values, req.Pagination)
+		return http.MethodGet, fmt.Sprintf("/sentinel/plans/%d/nodes?%s", req.Id, values.Encode()), nil
+	case *subscriptiontypes.QuerySubscriptionRequest:
+		return http.MethodGet, fmt.Sprintf("/sentinel/subscriptions/%d", req.Id), nil
+	case *subscriptiontypes.QueryAllocationRequest:
+		return http.MethodGet, fmt.Sprintf("/sentinel/subscriptions/%d/allocations/%s", req.Id, req.Address), nil
+	case *sessiontypes.QuerySessionRequest:
+		return http.MethodGet, fmt.Sprintf("/sentinel/sessions/%d", req.Id), nil
+	case *tmservice.GetLatestBlockRequest:
+		return http.MethodGet, "/cosmos/base/tendermint/v1beta1/blocks/latest", nil
+	case *txtypes.SimulateRequest:
+		return http.MethodPost, "/cosmos/tx/v1beta1/simulate", nil
+	case *txtypes.BroadcastTxRequest:
+		return http.MethodPost, "/cosmos/tx/v1beta1/txs", nil
+	case *txtypes.GetTxRequest:
+		return http.MethodGet, "/cosmos/tx/v1beta1/txs/" + req.Hash, nil
+	default:
+		return "", "", status.Errorf(codes.Unimplemented, "method %s is not supported over lcd", method)
+	}
+}
+
+func (c *lcdConn) Invoke(ctx context.Context, method string, args, reply interface{}, _ ...grpc.CallOption) error {
+	httpMethod, path, err := c.route(method, args)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if httpMethod == http.MethodPost {
+		buf, err := c.cdc.MarshalJSON(args.(proto.Message))
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, c.address+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// The gateway returns the gRPC status of the failed calls
+		var v struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+
+		if err = json.Unmarshal(buf, &v); err != nil || v.Code == 0 {
+			return fmt.Errorf("unexpected response status %s from %s", resp.Status, c.address)
+		}
+
+		return status.Error(codes.Code(v.Code), v.Message)
+	}
+
+	if buf, err = lcdStatuses(buf); err != nil {
+		return err
+	}
+
+	return c.cdc.UnmarshalJSON(buf, reply.(proto.Message))
+}
+
+// lcdStatuses rewrites the statuses of the hub items in a response, which the
+// gateway writes with the String method of the status, back to the names of
+// the enum values expected by the codec.
+func lcdStatuses(buf []byte) ([]byte, error) {
+	if !bytes.Contains(buf, []byte(`"status"`)) {
+		return buf, nil
+	}
+
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(buf))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if s, ok := item.(string); ok && key == "status" {
+					if status := hubtypes.StatusFromString(s); status.String() == s {
+						v[key] = hubtypes.Status_name[int32(status)]
+					}
+
+					continue
+				}
+
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+
+	walk(v)
+	return json.Marshal(v)
+}
+
+func (c *lcdConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streams are not supported over lcd")
+}
@@ -0,0 +1,651 @@
+package lite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/gogo/gateway"
+	gogogrpc "github.com/gogo/protobuf/grpc"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	hubtypes "github.com/sentinel-official/hub/types"
+	nodetypes "github.com/sentinel-official/hub/x/node/types"
+	sessiontypes "github.com/sentinel-official/hub/x/session/types"
+	subscriptiontypes "github.com/sentinel-official/hub/x/subscription/types"
+	vpntypes "github.com/sentinel-official/hub/x/vpn/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeChain is the state served by the fake remotes of every transport.
+type fakeChain struct {
+	accounts      map[string]authtypes.AccountI
+	balances      map[string]sdk.Coins
+	nodes         map[string]nodetypes.Node
+	plans         map[uint64][]nodetypes.Node
+	subscriptions map[uint64]subscriptiontypes.Subscription
+	allocations   map[string]subscriptiontypes.Allocation
+	sessions      map[uint64]sessiontypes.Session
+}
+
+func testAddress(i int) []byte {
+	return bytes.Repeat([]byte{byte(i)}, 20)
+}
+
+var (
+	testAccAddr   = sdk.AccAddress(testAddress(1))
+	testNodeAddr  = hubtypes.NodeAddress(testAddress(2))
+	testOtherAddr = hubtypes.NodeAddress(testAddress(3))
+)
+
+func newFakeChain() *fakeChain {
+	node := nodetypes.Node{
+		Address:        testNodeAddr.String(),
+		GigabytePrices: sdk.NewCoins(sdk.NewInt64Coin("udvpn", 1000)),
+		RemoteURL:      "https://node.example.com:7777",
+		Status:         hubtypes.StatusActive,
+	}
+
+	// The node is past the first page of the plan to cover the pagination
+	var plan []nodetypes.Node
+	for i := 0; i < 150; i++ {
+		plan = append(plan, nodetypes.Node{
+			Address: hubtypes.NodeAddress(append([]byte{0xff}, testAddress(i)[1:]...)).String(),
+			Status:  hubtypes.StatusActive,
+		})
+	}
+	plan = append(plan, node)
+
+	return &fakeChain{
+		accounts: map[string]authtypes.AccountI{
+			testAccAddr.String(): authtypes.NewBaseAccount(testAccAddr, nil, 5, 7),
+		},
+		balances: map[string]sdk.Coins{
+			testAccAddr.String(): sdk.NewCoins(sdk.NewInt64Coin("udvpn", 42)),
+		},
+		nodes: map[string]nodetypes.Node{
+			node.Address: node,
+		},
+		plans: map[uint64][]nodetypes.Node{
+			1: plan,
+		},
+		subscriptions: map[uint64]subscriptiontypes.Subscription{
+			10: &subscriptiontypes.NodeSubscription{
+				BaseSubscription: &subscriptiontypes.BaseSubscription{
+					ID:      10,
+					Address: testAccAddr.String(),
+					Status:  hubtypes.StatusActive,
+				},
+				NodeAddress: node.Address,
+				Gigabytes:   5,
+				Deposit:     sdk.NewInt64Coin("udvpn", 5000),
+			},
+		},
+		allocations: map[string]subscriptiontypes.Allocation{
+			fmt.Sprintf("10/%s", testAccAddr): {
+				ID:            10,
+				Address:       testAccAddr.String(),
+				GrantedBytes:  sdk.NewInt(5 << 30),
+				UtilisedBytes: sdk.NewInt(1 << 30),
+			},
+		},
+		sessions: map[uint64]sessiontypes.Session{
+			20: {
+				ID:             20,
+				SubscriptionID: 10,
+				NodeAddress:    node.Address,
+				Address:        testAccAddr.String(),
+				Bandwidth:      hubtypes.NewBandwidthFromInt64(300, 400),
+				Duration:       time.Minute,
+				Status:         hubtypes.StatusActive,
+			},
+		},
+	}
+}
+
+// hasStoreKey serves the store queries of the RPC transport, which look up
+// the plan membership of the nodes directly.
+func (c *fakeChain) hasStoreKey(key []byte) bool {
+	for id, items := range c.plans {
+		for _, item := range items {
+			nodeAddr, err := hubtypes.NodeAddressFromBech32(item.Address)
+			if err != nil {
+				panic(err)
+			}
+
+			prefix := []byte(nodetypes.ModuleName + "/")
+			if bytes.Equal(key, append(prefix, nodetypes.NodeForPlanKey(id, nodeAddr)...)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type fakeAuthQuery struct {
+	authtypes.UnimplementedQueryServer
+	*fakeChain
+}
+
+func (q *fakeAuthQuery) Account(_ context.Context, req *authtypes.QueryAccountRequest) (*authtypes.QueryAccountResponse, error) {
+	item, ok := q.accounts[req.Address]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "account %s not found", req.Address)
+	}
+
+	v, err := codectypes.NewAnyWithValue(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authtypes.QueryAccountResponse{Account: v}, nil
+}
+
+type fakeBankQuery struct {
+	banktypes.UnimplementedQueryServer
+	*fakeChain
+}
+
+func (q *fakeBankQuery) AllBalances(_ context.Context, req *banktypes.QueryAllBalancesRequest) (*banktypes.QueryAllBalancesResponse, error) {
+	return &banktypes.QueryAllBalancesResponse{Balances: q.balances[req.Address]}, nil
+}
+
+type fakeNodeQuery struct {
+	nodetypes.UnimplementedQueryServiceServer
+	*fakeChain
+}
+
+func (q *fakeNodeQuery) QueryNode(_ context.Context, req *nodetypes.QueryNodeRequest) (*nodetypes.QueryNodeResponse, error) {
+	item, ok := q.nodes[req.Address]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "node %s not found", req.Address)
+	}
+
+	return &nodetypes.QueryNodeResponse{Node: item}, nil
+}
+
+func (q *fakeNodeQuery) QueryNodesForPlan(_ context.Context, req *nodetypes.QueryNodesForPlanRequest) (*nodetypes.QueryNodesForPlanResponse, error) {
+	var (
+		items = q.plans[req.Id]
+		start = 0
+		limit = len(items)
+	)
+
+	if req.Pagination != nil {
+		if len(req.Pagination.Key) > 0 {
+			v, err := strconv.Atoi(string(req.Pagination.Key))
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+
+			start = v
+		}
+		if req.Pagination.Limit > 0 {
+			limit = int(req.Pagination.Limit)
+		}
+	}
+
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	res := &nodetypes.QueryNodesForPlanResponse{
+		Nodes:      items[start:end],
+		Pagination: &query.PageResponse{},
+	}
+	if end < len(items) {
+		res.Pagination.NextKey = []byte(strconv.Itoa(end))
+	}
+
+	return res, nil
+}
+
+type fakeSubscriptionQuery struct {
+	subscriptiontypes.UnimplementedQueryServiceServer
+	*fakeChain
+}
+
+func (q *fakeSubscriptionQuery) QuerySubscription(_ context.Context, req *subscriptiontypes.QuerySubscriptionRequest) (*subscriptiontypes.QuerySubscriptionResponse, error) {
+	item, ok := q.subscriptions[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "subscription %d not found", req.Id)
+	}
+
+	v, err := codectypes.NewAnyWithValue(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscriptiontypes.QuerySubscriptionResponse{Subscription: v}, nil
+}
+
+func (q *fakeSubscriptionQuery) QueryAllocation(_ context.Context, req *subscriptiontypes.QueryAllocationRequest) (*subscriptiontypes.QueryAllocationResponse, error) {
+	item, ok := q.allocations[fmt.Sprintf("%d/%s", req.Id, req.Address)]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "allocation %d/%s not found", req.Id, req.Address)
+	}
+
+	return &subscriptiontypes.QueryAllocationResponse{Allocation: item}, nil
+}
+
+type fakeSessionQuery struct {
+	sessiontypes.UnimplementedQueryServiceServer
+	*fakeChain
+}
+
+func (q *fakeSessionQuery) QuerySession(_ context.Context, req *sessiontypes.QuerySessionRequest) (*sessiontypes.QuerySessionResponse, error) {
+	item, ok := q.sessions[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "session %d not found", req.Id)
+	}
+
+	return &sessiontypes.QuerySessionResponse{Session: item}, nil
+}
+
+func (c *fakeChain) register(s gogogrpc.Server) {
+	authtypes.RegisterQueryServer(s, &fakeAuthQuery{fakeChain: c})
+	banktypes.RegisterQueryServer(s, &fakeBankQuery{fakeChain: c})
+	nodetypes.RegisterQueryServiceServer(s, &fakeNodeQuery{fakeChain: c})
+	subscriptiontypes.RegisterQueryServiceServer(s, &fakeSubscriptionQuery{fakeChain: c})
+	sessiontypes.RegisterQueryServiceServer(s, &fakeSessionQuery{fakeChain: c})
+}
+
+// newFakeRPCRemote serves the chain through the ABCI queries of a Tendermint
+// RPC server, routed the way the application of a node routes them.
+func newFakeRPCRemote(t *testing.T, chain *fakeChain) string {
+	t.Helper()
+
+	router := baseapp.NewGRPCQueryRouter()
+	chain.register(router)
+
+	abciQuery := func(_ *rpctypes.Context, path string, data tmbytes.HexBytes, height int64, _ bool) (*ctypes.ResultABCIQuery, error) {
+		if path == "/store/"+vpntypes.ModuleName+"/key" {
+			res := &ctypes.ResultABCIQuery{}
+			if chain.hasStoreKey(data) {
+				res.Response.Value = []byte{0x01}
+			}
+
+			return res, nil
+		}
+
+		handler := router.Route(path)
+		if handler == nil {
+			return &ctypes.ResultABCIQuery{Response: sdkerrors.QueryResult(sdkerrors.ErrUnknownRequest)}, nil
+		}
+
+		ctx := sdk.Context{}.WithContext(context.Background())
+		res, err := handler(ctx, abci.RequestQuery{Path: path, Data: data, Height: height})
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				err = sdkerrors.Wrap(sdkerrors.ErrKeyNotFound, err.Error())
+			}
+
+			return &ctypes.ResultABCIQuery{Response: sdkerrors.QueryResult(err)}, nil
+		}
+
+		return &ctypes.ResultABCIQuery{Response: res}, nil
+	}
+
+	mux := http.NewServeMux()
+	rpcserver.RegisterRPCFuncs(
+		mux,
+		map[string]*rpcserver.RPCFunc{
+			"abci_query": rpcserver.NewRPCFunc(abciQuery, "path,data,height,prove"),
+		},
+		tmlog.NewNopLogger(),
+	)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// newFakeGRPCRemote serves the services registered by register through a
+// gRPC server.
+func newFakeGRPCRemote(t *testing.T, register func(s gogogrpc.Server)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(gogoCodec{}))
+	register(server)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Stop)
+	return "grpc://" + listener.Addr().String()
+}
+
+// newFakeLCDRemote serves the chain through the routes of the REST gateway,
+// set up the way the API server of a node sets it up.
+func newFakeLCDRemote(t *testing.T, chain *fakeChain) string {
+	t.Helper()
+
+	var (
+		ctx = context.Background()
+		mux = runtime.NewServeMux(
+			runtime.WithMarshalerOption(
+				runtime.MIMEWildcard,
+				&gateway.JSONPb{
+					EmitDefaults: true,
+					OrigName:     true,
+					AnyResolver:  DefaultEncodingConfig().InterfaceRegistry,
+				},
+			),
+			runtime.WithProtoErrorHandler(runtime.DefaultHTTPProtoErrorHandler),
+		)
+	)
+
+	for _, err := range []error{
+		authtypes.RegisterQueryHandlerServer(ctx, mux, &fakeAuthQuery{fakeChain: chain}),
+		banktypes.RegisterQueryHandlerServer(ctx, mux, &fakeBankQuery{fakeChain: chain}),
+		nodetypes.RegisterQueryServiceHandlerServer(ctx, mux, &fakeNodeQuery{fakeChain: chain}),
+		subscriptiontypes.RegisterQueryServiceHandlerServer(ctx, mux, &fakeSubscriptionQuery{fakeChain: chain}),
+		sessiontypes.RegisterQueryServiceHandlerServer(ctx, mux, &fakeSessionQuery{fakeChain: chain}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return "lcd+" + server.URL
+}
+
+func TestClient_TransportContract(t *testing.T) {
+	var (
+		chain   = newFakeChain()
+		remotes = map[string]string{
+			TransportRPC:  newFakeRPCRemote(t, chain),
+			TransportGRPC: newFakeGRPCRemote(t, chain.register),
+			TransportLCD:  newFakeLCDRemote(t, chain),
+		}
+	)
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, c *Client)
+	}{
+		{
+			name: "account",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QueryAccount(testAccAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.GetAddress().Equals(testAccAddr) || result.GetAccountNumber() != 5 || result.GetSequence() != 7 {
+					t.Fatalf("unexpected account %v", result)
+				}
+			},
+		},
+		{
+			name: "account not found",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QueryAccount(sdk.AccAddress(testOtherAddr))
+				if err != nil || result != nil {
+					t.Fatalf("expected no account and no error, got %v and %v", result, err)
+				}
+			},
+		},
+		{
+			name: "balances",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QueryBalances(testAccAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.IsEqual(chain.balances[testAccAddr.String()]) {
+					t.Fatalf("unexpected balances %s", result)
+				}
+			},
+		},
+		{
+			name: "node",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QueryNode(testNodeAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want := chain.nodes[testNodeAddr.String()]
+				if result == nil || result.Address != want.Address || result.RemoteURL != want.RemoteURL ||
+					!result.Status.Equal(want.Status) || !result.GigabytePrices.IsEqual(want.GigabytePrices) {
+					t.Fatalf("unexpected node %v", result)
+				}
+			},
+		},
+		{
+			name: "node not found",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QueryNode(testOtherAddr)
+				if err != nil || result != nil {
+					t.Fatalf("expected no node and no error, got %v and %v", result, err)
+				}
+			},
+		},
+		{
+			name: "subscription",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QuerySubscription(10)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				item, ok := result.(*subscriptiontypes.NodeSubscription)
+				if !ok {
+					t.Fatalf("expected a node subscription, got %T", result)
+				}
+				if item.GetID() != 10 || item.NodeAddress != testNodeAddr.String() || item.Gigabytes != 5 ||
+					!item.GetStatus().Equal(hubtypes.StatusActive) {
+					t.Fatalf("unexpected subscription %v", item)
+				}
+			},
+		},
+		{
+			name: "subscription not found",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QuerySubscription(11)
+				if err != nil || result != nil {
+					t.Fatalf("expected no subscription and no error, got %v and %v", result, err)
+				}
+			},
+		},
+		{
+			name: "allocation",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QueryAllocation(10, testAccAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result == nil || result.Address != testAccAddr.String() ||
+					!result.GrantedBytes.Equal(sdk.NewInt(5<<30)) || !result.UtilisedBytes.Equal(sdk.NewInt(1<<30)) {
+					t.Fatalf("unexpected allocation %v", result)
+				}
+			},
+		},
+		{
+			name: "session",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QuerySession(20)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result == nil || result.SubscriptionID != 10 || result.Duration != time.Minute ||
+					!result.Bandwidth.Upload.Equal(sdk.NewInt(300)) || !result.Bandwidth.Download.Equal(sdk.NewInt(400)) {
+					t.Fatalf("unexpected session %v", result)
+				}
+			},
+		},
+		{
+			name: "session not found",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.QuerySession(21)
+				if err != nil || result != nil {
+					t.Fatalf("expected no session and no error, got %v and %v", result, err)
+				}
+			},
+		},
+		{
+			name: "node for plan",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.HasNodeForPlan(1, testNodeAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result {
+					t.Fatal("expected the node to be in the plan")
+				}
+			},
+		},
+		{
+			name: "node not for plan",
+			run: func(t *testing.T, c *Client) {
+				result, err := c.HasNodeForPlan(1, testOtherAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result {
+					t.Fatal("expected the node not to be in the plan")
+				}
+			},
+		},
+	}
+
+	for _, transport := range []string{TransportRPC, TransportGRPC, TransportLCD} {
+		client := NewDefaultClient().
+			WithLogger(tmlog.NewNopLogger()).
+			WithQueryTimeout(5).
+			WithRemotes([]string{remotes[transport]})
+
+		for _, tt := range tests {
+			t.Run(transport+"/"+tt.name, func(t *testing.T) {
+				tt.run(t, client)
+			})
+		}
+	}
+}
+
+func TestLCDConn_Route(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   interface{}
+		method string
+		path   string
+	}{
+		{
+			name:   "account",
+			args:   &authtypes.QueryAccountRequest{Address: testAccAddr.String()},
+			method: http.MethodGet,
+			path:   "/cosmos/auth/v1beta1/accounts/" + testAccAddr.String(),
+		},
+		{
+			name:   "balances",
+			args:   banktypes.NewQueryAllBalancesRequest(testAccAddr, &query.PageRequest{Key: []byte{0x01}, Limit: 10}),
+			method: http.MethodGet,
+			path:   "/cosmos/bank/v1beta1/balances/" + testAccAddr.String() + "?pagination.key=AQ%3D%3D&pagination.limit=10",
+		},
+		{
+			name:   "node",
+			args:   nodetypes.NewQueryNodeRequest(testNodeAddr),
+			method: http.MethodGet,
+			path:   "/sentinel/nodes/" + testNodeAddr.String(),
+		},
+		{
+			name:   "nodes for plan",
+			args:   nodetypes.NewQueryNodesForPlanRequest(1, hubtypes.StatusActive, &query.PageRequest{Limit: 100}),
+			method: http.MethodGet,
+			path:   "/sentinel/plans/1/nodes?pagination.limit=100&status=STATUS_ACTIVE",
+		},
+		{
+			name:   "subscription",
+			args:   subscriptiontypes.NewQuerySubscriptionRequest(10),
+			method: http.MethodGet,
+			path:   "/sentinel/subscriptions/10",
+		},
+		{
+			name:   "allocation",
+			args:   subscriptiontypes.NewQueryAllocationRequest(10, testAccAddr),
+			method: http.MethodGet,
+			path:   "/sentinel/subscriptions/10/allocations/" + testAccAddr.String(),
+		},
+		{
+			name:   "session",
+			args:   sessiontypes.NewQuerySessionRequest(20),
+			method: http.MethodGet,
+			path:   "/sentinel/sessions/20",
+		},
+		{
+			name:   "latest block",
+			args:   &tmservice.GetLatestBlockRequest{},
+			method: http.MethodGet,
+			path:   "/cosmos/base/tendermint/v1beta1/blocks/latest",
+		},
+		{
+			name:   "simulate",
+			args:   &txtypes.SimulateRequest{},
+			method: http.MethodPost,
+			path:   "/cosmos/tx/v1beta1/simulate",
+		},
+		{
+			name:   "broadcast",
+			args:   &txtypes.BroadcastTxRequest{},
+			method: http.MethodPost,
+			path:   "/cosmos/tx/v1beta1/txs",
+		},
+		{
+			name:   "tx",
+			args:   &txtypes.GetTxRequest{Hash: "ABCD"},
+			method: http.MethodGet,
+			path:   "/cosmos/tx/v1beta1/txs/ABCD",
+		},
+	}
+
+	conn := &lcdConn{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, path, err := conn.route("", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.method || path != tt.path {
+				t.Fatalf("expected %s %s, got %s %s", tt.method, tt.path, method, path)
+			}
+		})
+	}
+
+	if _, _, err := conn.route("/cosmos.bank.v1beta1.Query/Balance", &banktypes.QueryBalanceRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected an unimplemented error, got %v", err)
+	}
+}
@@ -1,28 +1,71 @@
 package lite
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/avast/retry-go/v4"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
 	"github.com/cosmos/cosmos-sdk/client/tx"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
-	"github.com/pkg/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/x/authz"
 	abcitypes "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
 	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
 )
 
+const (
+	txPollInterval = 2 * time.Second
+)
+
+func broadcastMode(v string) txtypes.BroadcastMode {
+	switch v {
+	case flags.BroadcastSync:
+		return txtypes.BroadcastMode_BROADCAST_MODE_SYNC
+	case flags.BroadcastAsync:
+		return txtypes.BroadcastMode_BROADCAST_MODE_ASYNC
+	default:
+		return txtypes.BroadcastMode_BROADCAST_MODE_BLOCK
+	}
+}
+
 func (c *Client) broadcastTx(remote string, txBytes []byte) (*sdk.TxResponse, error) {
 	c.log.Debug("Broadcasting the transaction", "remote", remote, "size", len(txBytes))
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.txTimeout)
+	conn, err := c.conn(remote, c.txTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := c.ctx.WithClient(client)
+	var resp *sdk.TxResponse
+	if ctx, ok := conn.(client.Context); ok {
+		resp, err = ctx.BroadcastTx(txBytes)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ctx, cancel := c.txContext()
+		defer cancel()
+
+		res, err := txtypes.NewServiceClient(conn).BroadcastTx(
+			ctx,
+			&txtypes.BroadcastTxRequest{
+				TxBytes: txBytes,
+				Mode:    broadcastMode(c.ctx.BroadcastMode),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := ctx.BroadcastTx(txBytes)
-	if err != nil {
-		return nil, err
+		resp = res.TxResponse
 	}
 
 	switch resp.Code {
@@ -31,7 +74,11 @@ func (c *Client) broadcastTx(remote string, txBytes []byte) (*sdk.TxResponse, er
 	case sdkerrors.ErrTxInMempoolCache.ABCICode():
 		return resp, nil
 	default:
-		return nil, errors.New(resp.RawLog)
+		return nil, &TxError{
+			Code:      resp.Code,
+			Codespace: resp.Codespace,
+			Log:       resp.RawLog,
+		}
 	}
 }
 
@@ -42,11 +89,24 @@ func (c *Client) BroadcastTx(txBytes []byte) (res *sdk.TxResponse, err error) {
 		}
 	}()
 
-	for i := 0; i < len(c.remotes); i++ {
-		res, err = c.broadcastTx(c.remotes[i], txBytes)
+	timedOut := false
+
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		res, err = c.broadcastTx(remotes[i], txBytes)
+		c.observe("broadcast_tx", remotes[i], start, err)
 		if err == nil {
 			break
 		}
+
+		timedOut = timedOut || isTimeoutError(err)
+	}
+	if err != nil && timedOut {
+		return nil, &UnknownTxError{
+			TxHash: strings.ToUpper(hex.EncodeToString(tmhash.Sum(txBytes))),
+			Err:    err,
+		}
 	}
 	if err != nil {
 		return nil, err
@@ -58,14 +118,12 @@ func (c *Client) BroadcastTx(txBytes []byte) (res *sdk.TxResponse, err error) {
 func (c *Client) calculateGas(remote string, txf tx.Factory, messages ...sdk.Msg) (uint64, error) {
 	c.log.Debug("Calculating the gas", "remote", remote, "messages", len(messages))
 
-	client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.txTimeout)
+	conn, err := c.conn(remote, c.txTimeout)
 	if err != nil {
 		return 0, err
 	}
 
-	ctx := c.ctx.WithClient(client)
-
-	_, gas, err := tx.CalculateGas(ctx, txf, messages...)
+	_, gas, err := tx.CalculateGas(conn, txf, messages...)
 	if err != nil {
 		return 0, err
 	}
@@ -74,8 +132,11 @@ func (c *Client) calculateGas(remote string, txf tx.Factory, messages ...sdk.Msg
 }
 
 func (c *Client) CalculateGas(txf tx.Factory, messages ...sdk.Msg) (gas uint64, err error) {
-	for i := 0; i < len(c.remotes); i++ {
-		gas, err = c.calculateGas(c.remotes[i], txf, messages...)
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		gas, err = c.calculateGas(remotes[i], txf, messages...)
+		c.observe("calculate_gas", remotes[i], start, err)
 		if err == nil {
 			break
 		}
@@ -95,14 +156,14 @@ func (c *Client) PrepareTxFactory(messages ...sdk.Msg) (txf tx.Factory, err erro
 		}
 	}()
 
-	acc, err := c.QueryAccount(c.FromAddress())
+	accountNumber, sequence, err := c.sequence.get(c)
 	if err != nil {
 		return txf, err
 	}
 
 	txf = c.txf.
-		WithAccountNumber(acc.GetAccountNumber()).
-		WithSequence(acc.GetSequence())
+		WithAccountNumber(accountNumber).
+		WithSequence(sequence)
 
 	if c.SimulateAndExecute() {
 		gas, err := c.CalculateGas(txf, messages...)
@@ -116,8 +177,146 @@ func (c *Client) PrepareTxFactory(messages ...sdk.Msg) (txf tx.Factory, err erro
 	return txf, nil
 }
 
-func (c *Client) tx(messages ...sdk.Msg) (res *sdk.TxResponse, err error) {
+// wrapMessages wraps the messages in an authz MsgExec when the client acts on
+// behalf of a granter.
+func (c *Client) wrapMessages(messages []sdk.Msg) []sdk.Msg {
+	if c.authzGranter == nil {
+		return messages
+	}
+
+	msg := authz.NewMsgExec(c.FromAddress(), messages)
+	return []sdk.Msg{&msg}
+}
+
+// SimulateGas returns the adjusted gas the messages would consume when
+// executed in a single transaction.
+func (c *Client) SimulateGas(messages ...sdk.Msg) (uint64, error) {
+	messages = c.wrapMessages(messages)
+
+	accountNumber, sequence, err := c.sequence.get(c)
+	if err != nil {
+		return 0, err
+	}
+
+	txf := c.txf.
+		WithAccountNumber(accountNumber).
+		WithSequence(sequence)
+
+	gas, err := c.CalculateGas(txf, messages...)
+	if IsSequenceError(err) {
+		c.sequence.reset()
+	}
+
+	return gas, err
+}
+
+func (c *Client) queryTx(remote string, hash []byte) (*sdk.TxResponse, error) {
+	transport, _, err := RemoteTransport(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.queryContext()
+	defer cancel()
+
+	if transport == TransportRPC {
+		client, err := rpchttp.NewWithTimeout(remote, "/websocket", c.queryTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := client.Tx(ctx, hash, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return sdk.NewResponseResultTx(res, nil, ""), nil
+	}
+
+	conn, err := c.conn(remote, c.queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := txtypes.NewServiceClient(conn).GetTx(
+		ctx,
+		&txtypes.GetTxRequest{
+			Hash: strings.ToUpper(hex.EncodeToString(hash)),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.TxResponse, nil
+}
+
+// QueryTx returns the result of an included transaction.
+func (c *Client) QueryTx(hash []byte) (res *sdk.TxResponse, err error) {
+	remotes := c.Remotes()
+	for i := 0; i < len(remotes); i++ {
+		start := time.Now()
+		res, err = c.queryTx(remotes[i], hash)
+		c.observe("query_tx", remotes[i], start, err)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// WaitTx polls the remotes for the transaction until it is included in a
+// block or the confirmation timeout elapses.
+func (c *Client) WaitTx(hash string) (*sdk.TxResponse, error) {
+	buf, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(time.Duration(c.confirmTimeout) * time.Second)
+	for {
+		res, err := c.QueryTx(buf)
+		if err == nil {
+			if res.Code != abcitypes.CodeTypeOK {
+				return res, &TxError{
+					Code:      res.Code,
+					Codespace: res.Codespace,
+					Log:       res.RawLog,
+				}
+			}
+
+			return res, nil
+		}
+
+		if time.Now().Add(txPollInterval).After(deadline) {
+			return nil, fmt.Errorf("transaction %s was not confirmed within %ds; %w", hash, c.confirmTimeout, err)
+		}
+
+		time.Sleep(txPollInterval)
+	}
+}
+
+// broadcast signs the messages with the next sequence and broadcasts them. The
+// sequence is only advanced once the transaction is accepted, and the lock is
+// released before waiting for its confirmation.
+func (c *Client) broadcast(messages ...sdk.Msg) (res *sdk.TxResponse, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	defer func() {
+		if IsSequenceError(err) {
+			c.log.Info("Resyncing the account sequence", "error", err)
+			c.sequence.reset()
+		}
+	}()
+
 	c.log.Info("Preparing the transaction", "messages", len(messages))
+	messages = c.wrapMessages(messages)
+
 	txf, err := c.PrepareTxFactory(messages...)
 	if err != nil {
 		return nil, err
@@ -129,6 +328,8 @@ func (c *Client) tx(messages ...sdk.Msg) (res *sdk.TxResponse, err error) {
 		return nil, err
 	}
 
+	txb.SetFeeGranter(c.FeeGranter())
+
 	if err = tx.Sign(txf, c.FromName(), txb, true); err != nil {
 		return nil, err
 	}
@@ -140,33 +341,84 @@ func (c *Client) tx(messages ...sdk.Msg) (res *sdk.TxResponse, err error) {
 
 	c.log.Info("Broadcasting the transaction", "size", len(txBytes))
 	res, err = c.BroadcastTx(txBytes)
-	if err != nil {
+
+	var unknownErr *UnknownTxError
+	if errors.As(err, &unknownErr) {
+		// The transaction may hold the sequence in a mempool, so the sequence
+		// is synced from the chain again rather than advanced.
+		c.log.Info("Resyncing the account sequence", "error", err)
+		c.sequence.reset()
+
+		res = &sdk.TxResponse{TxHash: unknownErr.TxHash}
+	} else if err != nil {
 		return nil, err
+	} else {
+		c.sequence.increment()
 	}
 
-	return res, nil
+	// The responses of the sync and async modes do not carry the transaction,
+	// which holds the fee it was signed with.
+	if v, ok := txb.GetTx().(interface{ GetProtoTx() *txtypes.Tx }); ok && res.Tx == nil {
+		tx, err := codectypes.NewAnyWithValue(v.GetProtoTx())
+		if err != nil {
+			return nil, err
+		}
+
+		res.Tx = tx
+	}
+
+	// The transaction of an unknown outcome is returned along with the error,
+	// so that its hash can be polled.
+	return res, err
 }
 
+// Tx broadcasts the messages, retrying the broadcasts which fail to reach the
+// remotes or hit a sequence mismatch, and waits for the inclusion of the
+// accepted transaction. A transaction is never broadcast again once accepted,
+// since the retry would include it twice; on a confirmation failure the result
+// of the broadcast, or of the failed execution, is returned along with the
+// error. A transaction none of the remotes answered in time is looked up by its
+// hash instead, and an UnknownTxError is returned when it is not found.
 func (c *Client) Tx(messages ...sdk.Msg) (res *sdk.TxResponse, err error) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
 	err = retry.Do(
 		func() error {
-			res, err = c.tx(messages...)
-			if err != nil {
-				return err
-			}
-
-			c.log.Info("Transaction result", "code", res.Code,
-				"codespace", res.Codespace, "height", res.Height, "tx_hash", res.TxHash)
-			return nil
+			res, err = c.broadcast(messages...)
+			return err
 		},
 		retry.Attempts(5),
+		retry.RetryIf(isRetryableTxError),
 	)
-	if err != nil {
+
+	unknown := IsUnknownTxError(err)
+	if err != nil && (!unknown || c.confirmTimeout == 0) {
 		return nil, err
 	}
 
+	if unknown || (c.ctx.BroadcastMode != flags.BroadcastBlock && c.confirmTimeout > 0) {
+		c.log.Info("Waiting for the transaction confirmation", "tx_hash", res.TxHash)
+
+		unknownErr := err
+		result, err := c.WaitTx(res.TxHash)
+		if result != nil && result.Tx == nil {
+			result.Tx = res.Tx
+		}
+		if err != nil {
+			// A transaction which failed in DeliverTx is returned with its
+			// result, as it is included and its fee is paid all the same.
+			if result != nil {
+				return result, err
+			}
+			if unknown {
+				return nil, unknownErr
+			}
+
+			return res, err
+		}
+
+		res = result
+	}
+
+	c.log.Info("Transaction result", "code", res.Code,
+		"codespace", res.Codespace, "height", res.Height, "tx_hash", res.TxHash)
 	return res, nil
 }
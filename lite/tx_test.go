@@ -0,0 +1,230 @@
+package lite
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	gogogrpc "github.com/gogo/protobuf/grpc"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeTxService accepts the broadcast transactions after failing the first
+// ones with the given errors, and reports them as included with the result
+// code when included is set.
+type fakeTxService struct {
+	txtypes.UnimplementedServiceServer
+
+	mutex      sync.Mutex
+	errs       []error
+	included   bool
+	code       uint32
+	broadcasts int
+}
+
+func (s *fakeTxService) BroadcastTx(_ context.Context, req *txtypes.BroadcastTxRequest) (*txtypes.BroadcastTxResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.broadcasts++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+
+		if txErr, ok := err.(*TxError); ok {
+			return &txtypes.BroadcastTxResponse{
+				TxResponse: &sdk.TxResponse{
+					Code:      txErr.Code,
+					Codespace: txErr.Codespace,
+					RawLog:    txErr.Log,
+				},
+			}, nil
+		}
+
+		return nil, err
+	}
+
+	return &txtypes.BroadcastTxResponse{
+		TxResponse: &sdk.TxResponse{
+			TxHash: strings.ToUpper(hex.EncodeToString(tmhash.Sum(req.TxBytes))),
+		},
+	}, nil
+}
+
+func (s *fakeTxService) GetTx(_ context.Context, req *txtypes.GetTxRequest) (*txtypes.GetTxResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.included {
+		return nil, status.Errorf(codes.NotFound, "tx %s not found", req.Hash)
+	}
+
+	return &txtypes.GetTxResponse{
+		TxResponse: &sdk.TxResponse{
+			Code:   s.code,
+			Height: 10,
+			TxHash: req.Hash,
+		},
+	}, nil
+}
+
+func newTestTxClient(t *testing.T, remote string, confirmTimeout uint) *Client {
+	t.Helper()
+
+	kr := keyring.NewInMemory()
+	info, _, err := kr.NewMnemonic("test", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewDefaultClient().
+		WithBroadcastMode(flags.BroadcastSync).
+		WithChainID("test").
+		WithConfirmTimeout(confirmTimeout).
+		WithFromAddress(info.GetAddress()).
+		WithFromName(info.GetName()).
+		WithGas(200_000).
+		WithGasPrices("0.1udvpn").
+		WithKeyring(kr).
+		WithLogger(tmlog.NewNopLogger()).
+		WithQueryTimeout(5).
+		WithRemotes([]string{remote}).
+		WithTxTimeout(5)
+}
+
+func TestClient_Tx(t *testing.T) {
+	sequenceErr := &TxError{
+		Code:      sdkerrors.ErrWrongSequence.ABCICode(),
+		Codespace: sdkerrors.ErrWrongSequence.Codespace(),
+		Log:       "account sequence mismatch",
+	}
+
+	tests := []struct {
+		name           string
+		errs           []error
+		included       bool
+		code           uint32
+		confirmTimeout uint
+		wantErr        bool
+		wantResult     bool
+		wantUnknown    bool
+		wantBroadcasts int
+	}{
+		{
+			name:           "accepted without waiting",
+			wantBroadcasts: 1,
+		},
+		{
+			name:           "included",
+			included:       true,
+			confirmTimeout: 1,
+			wantBroadcasts: 1,
+		},
+		{
+			name:           "unreachable remote is retried",
+			errs:           []error{status.Error(codes.Unavailable, "unavailable")},
+			wantBroadcasts: 2,
+		},
+		{
+			name:           "sequence mismatch is retried",
+			errs:           []error{sequenceErr},
+			wantBroadcasts: 2,
+		},
+		{
+			name: "rejected by the chain",
+			errs: []error{
+				&TxError{Code: sdkerrors.ErrInsufficientFunds.ABCICode(), Codespace: sdkerrors.RootCodespace},
+			},
+			wantErr:        true,
+			wantBroadcasts: 1,
+		},
+		{
+			name:           "not confirmed is not broadcast again",
+			confirmTimeout: 1,
+			wantErr:        true,
+			wantBroadcasts: 1,
+		},
+		{
+			name:           "timed out on every remote is not broadcast again",
+			errs:           []error{status.Error(codes.DeadlineExceeded, "deadline exceeded")},
+			confirmTimeout: 1,
+			wantErr:        true,
+			wantUnknown:    true,
+			wantBroadcasts: 1,
+		},
+		{
+			name:           "timed out on every remote is found by the hash",
+			errs:           []error{status.Error(codes.DeadlineExceeded, "deadline exceeded")},
+			included:       true,
+			confirmTimeout: 1,
+			wantUnknown:    true,
+			wantBroadcasts: 1,
+		},
+		{
+			name:           "failed in DeliverTx is returned with the result",
+			included:       true,
+			code:           sdkerrors.ErrOutOfGas.ABCICode(),
+			confirmTimeout: 1,
+			wantErr:        true,
+			wantResult:     true,
+			wantBroadcasts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				chain   = newFakeChain()
+				service = &fakeTxService{errs: tt.errs, included: tt.included, code: tt.code}
+				remote  = newFakeGRPCRemote(t, func(s gogogrpc.Server) {
+					chain.register(s)
+					txtypes.RegisterServiceServer(s, service)
+				})
+				client = newTestTxClient(t, remote, tt.confirmTimeout)
+			)
+
+			chain.accounts[client.FromAddress().String()] = authtypes.NewBaseAccount(client.FromAddress(), nil, 5, 7)
+
+			msg := banktypes.NewMsgSend(client.FromAddress(), testAccAddr, sdk.NewCoins(sdk.NewInt64Coin("udvpn", 1)))
+			res, err := client.Tx(msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if service.broadcasts != tt.wantBroadcasts {
+				t.Fatalf("expected %d broadcasts, got %d", tt.wantBroadcasts, service.broadcasts)
+			}
+			if tt.wantErr && IsUnknownTxError(err) != tt.wantUnknown {
+				t.Fatalf("expected an unknown outcome %t, got %v", tt.wantUnknown, err)
+			}
+			if tt.wantUnknown && client.sequence.synced {
+				t.Fatal("expected the sequence to be synced from the chain again")
+			}
+			if err != nil && !tt.wantResult {
+				return
+			}
+
+			if res.TxHash == "" {
+				t.Fatal("expected the hash of the transaction")
+			}
+			if tt.included && (res.Height != 10 || res.Code != tt.code) {
+				t.Fatalf("expected the result of the included transaction, got %v", res)
+			}
+			if tx, ok := res.GetTx().(*txtypes.Tx); !ok || tx.AuthInfo.Fee.GasLimit != 200_000 {
+				t.Fatalf("expected the result to carry the signed transaction, got %v", res.Tx)
+			}
+		})
+	}
+}
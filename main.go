@@ -22,7 +22,10 @@ func main() {
 
 	root.AddCommand(
 		cmd.ConfigCmd(),
+		cmd.EarningsCmd(),
+		cmd.FeesCmd(),
 		cmd.KeysCmd(),
+		cmd.SessionsCmd(),
 		v2ray.Command(),
 		wireguard.Command(),
 		cmd.StartCmd(),
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "sentinelnode"
+)
+
+var (
+	Peers = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "peers",
+			Help:      "Number of connected peers per service type.",
+		},
+		[]string{"type"},
+	)
+	Bandwidth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "bandwidth_bytes_per_second",
+			Help:      "Bandwidth of the node measured by the last speed test.",
+		},
+		[]string{"direction"},
+	)
+	SpeedTestLatency = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "speed_test",
+			Name:      "latency_seconds",
+			Help:      "Latency measured by the last speed test.",
+		},
+	)
+	SpeedTestJitter = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "speed_test",
+			Name:      "jitter_seconds",
+			Help:      "Jitter measured by the last speed test.",
+		},
+	)
+	SessionUpload = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "session",
+			Name:      "upload_bytes",
+			Help:      "Bytes uploaded by the peer of a session.",
+		},
+		[]string{"id"},
+	)
+	SessionDownload = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "session",
+			Name:      "download_bytes",
+			Help:      "Bytes downloaded by the peer of a session.",
+		},
+		[]string{"id"},
+	)
+	ChainRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "chain",
+			Name:      "requests_total",
+			Help:      "Number of queries and transactions sent to the chain per remote and outcome.",
+		},
+		[]string{"method", "remote", "outcome"},
+	)
+	ChainRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "chain",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of queries and transactions sent to the chain per remote.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "remote"},
+	)
+	ChainCacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "chain",
+			Name:      "cache_requests_total",
+			Help:      "Number of chain queries looked up in the cache per query and result (hit or miss).",
+		},
+		[]string{"query", "result"},
+	)
+	FeePayerBalance = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "fee_payer",
+			Name:      "balance",
+			Help:      "Balance of the account paying the transaction fees per denom.",
+		},
+		[]string{"denom"},
+	)
+	FeePayerRunway = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "fee_payer",
+			Name:      "runway_seconds",
+			Help:      "Time the balance lasts at the rate of the recent transaction fees per denom.",
+		},
+		[]string{"denom"},
+	)
+	JobDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "job",
+			Name:      "duration_seconds",
+			Help:      "Duration of each tick of the node jobs.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+		},
+		[]string{"name", "outcome"},
+	)
+	AddSessionResults = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "api",
+			Name:      "add_session_total",
+			Help:      "Number of add session requests per response error code, where 0 is success.",
+		},
+		[]string{"code"},
+	)
+	IPPoolReserved = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ip_pool",
+			Name:      "reserved",
+			Help:      "Number of reserved addresses in the WireGuard IP pool per family.",
+		},
+		[]string{"family"},
+	)
+)
+
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "success"
+}
+
+func ObserveChainRequest(method, remote string, start time.Time, err error) {
+	ChainRequests.WithLabelValues(method, remote, Outcome(err)).Inc()
+	ChainRequestDuration.WithLabelValues(method, remote).Observe(time.Since(start).Seconds())
+}
+
+func ObserveJob(name string, start time.Time, err error) {
+	JobDuration.WithLabelValues(name, Outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
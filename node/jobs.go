@@ -1,6 +1,9 @@
 package node
 
 import (
+	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -8,186 +11,524 @@ import (
 	sessiontypes "github.com/sentinel-official/hub/x/session/types"
 	subscriptiontypes "github.com/sentinel-official/hub/x/subscription/types"
 
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth"
+	"github.com/sentinel-official/dvpn-node/metrics"
 	"github.com/sentinel-official/dvpn-node/types"
+	"github.com/sentinel-official/dvpn-node/utils"
 )
 
-func (n *Node) jobSetSessions() error {
-	n.Log().Info("Starting a job", "name", "set_sessions", "interval", n.IntervalSetSessions())
+func (n *Node) setSessions() error {
+	peers, err := n.Service().Peers()
+	if err != nil {
+		return err
+	}
 
-	t := time.NewTicker(n.IntervalSetSessions())
-	for ; ; <-t.C {
-		peers, err := n.Service().Peers()
+	count := len(peers)
+	n.Log().Debug("Validating the peers", "count", count)
+
+	metrics.SessionUpload.Reset()
+	metrics.SessionDownload.Reset()
+
+	for i := 0; i < count; i++ {
+		var item types.Session
+		n.Database().Model(
+			&types.Session{},
+		).Where(
+			&types.Session{
+				Key: peers[i].Key,
+			},
+		).First(&item)
+
+		if item.ID == 0 {
+			n.Log().Info("Unknown connected peer", "key", peers[i].Key)
+			if err = n.RemovePeer(peers[i].Key); err != nil {
+				return err
+			}
+
+			n.RecordSessionEvent(types.SessionEventRemovePeer, &types.Session{Key: peers[i].Key}, "unknown peer")
+
+			continue
+		}
+
+		id := strconv.FormatUint(item.ID, 10)
+		metrics.SessionUpload.WithLabelValues(id).Set(float64(peers[i].Upload))
+		metrics.SessionDownload.WithLabelValues(id).Set(float64(peers[i].Download))
+
+		if item.Upload == peers[i].Upload {
+			n.Log().Debug("The peer has not sent any data", "key", item.Key,
+				"update_at", item.UpdatedAt)
+			continue
+		}
+
+		n.Database().Model(
+			&types.Session{},
+		).Where(
+			&types.Session{
+				ID: item.ID,
+			},
+		).Updates(
+			&types.Session{
+				Upload:   peers[i].Upload,
+				Download: peers[i].Download,
+			},
+		)
+
+		var (
+			available = sdk.NewInt(item.Available)
+			consumed  = sdk.NewInt(peers[i].Upload + peers[i].Download)
+		)
+
+		if available.IsPositive() && consumed.GT(available) {
+			n.Log().Info("Peer allocation exceeded", "key", item.Key)
+
+			item.Upload, item.Download = peers[i].Upload, peers[i].Download
+			reason := fmt.Sprintf("consumed %s of available %s bytes", consumed, available)
+
+			n.RecordSessionEvent(types.SessionEventAllocationExceeded, &item, reason)
+			if err = n.RemoveSessionPeer(&item, types.SessionEventAllocationExceeded); err != nil {
+				return err
+			}
+		}
+	}
+
+	metrics.Peers.WithLabelValues(n.Config().Node.Type).Set(float64(n.Service().PeerCount()))
+
+	return nil
+}
+
+type sessionEvent struct {
+	t      string
+	reason string
+}
+
+func (n *Node) updateSessions() error {
+	var items []types.Session
+	n.Database().Model(
+		&types.Session{},
+	).Find(&items)
+
+	count := len(items)
+	n.Log().Info("Validating the sessions", "count", count)
+
+	for i := count - 1; i >= 0; i-- {
+		session, err := n.Client().QuerySession(items[i].ID)
 		if err != nil {
 			return err
 		}
+		if session == nil {
+			session = &sessiontypes.Session{
+				ID:             items[i].ID,
+				SubscriptionID: items[i].Subscription,
+				Bandwidth:      hubtypes.NewBandwidthFromInt64(items[i].Upload, items[i].Download),
+				Status:         hubtypes.StatusInactive,
+			}
+		}
 
-		count := len(peers)
-		n.Log().Debug("Validating the peers", "count", count)
-
-		for i := 0; i < count; i++ {
-			var item types.Session
-			n.Database().Model(
-				&types.Session{},
-			).Where(
-				&types.Session{
-					Key: peers[i].Key,
+		subscription, err := n.Client().QuerySubscription(session.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if subscription == nil {
+			subscription = &subscriptiontypes.NodeSubscription{
+				BaseSubscription: &subscriptiontypes.BaseSubscription{
+					ID:     items[i].Subscription,
+					Status: hubtypes.StatusInactive,
 				},
-			).First(&item)
+			}
+		}
 
-			if item.ID == 0 {
-				n.Log().Info("Unknown connected peer", "key", peers[i].Key)
-				if err = n.RemovePeer(peers[i].Key); err != nil {
-					return err
-				}
+		var (
+			removePeer    = false
+			removeSession = false
+			skipUpdate    = false
+			reason        = ""
+			events        []sessionEvent
+		)
 
-				continue
+		if items[i].Upload == session.Bandwidth.Upload.Int64() {
+			skipUpdate = true
+			if items[i].CreatedAt.Before(session.StatusAt) {
+				removePeer = true
 			}
-			if item.Upload == peers[i].Upload {
-				n.Log().Debug("The peer has not sent any data", "key", item.Key,
-					"update_at", item.UpdatedAt)
-				continue
+
+			n.Log().Info("Stale peer connection", "key", items[i].Key,
+				"created_at", items[i].CreatedAt, "status_at", session.StatusAt)
+
+			reason = types.SessionEventStalePeer
+			events = append(events, sessionEvent{t: types.SessionEventStalePeer})
+		}
+		if !subscription.GetStatus().Equal(hubtypes.StatusActive) {
+			removePeer = true
+			if subscription.GetStatus().Equal(hubtypes.StatusInactive) {
+				removeSession, skipUpdate = true, true
+			}
+
+			n.Log().Info("Invalid subscription status", "key", items[i].Key,
+				"id", subscription.GetID(), "status", subscription.GetStatus())
+
+			reason = fmt.Sprintf("subscription %d status %s", subscription.GetID(), subscription.GetStatus())
+			events = append(events, sessionEvent{t: types.SessionEventStatusChange, reason: reason})
+		}
+		if !session.Status.Equal(hubtypes.StatusActive) {
+			removePeer = true
+			if session.Status.Equal(hubtypes.StatusInactive) {
+				removeSession, skipUpdate = true, true
+			}
+
+			n.Log().Info("Invalid session status", "key", items[i].Key,
+				"id", session.ID, "status", session.Status)
+
+			reason = fmt.Sprintf("session status %s", session.Status)
+			events = append(events, sessionEvent{t: types.SessionEventStatusChange, reason: reason})
+		}
+
+		// The checks repeat on every run until the session goes away, so the
+		// events are recorded only when the peer or the session is removed.
+		if removePeer {
+			ok, err := n.HasPeer(items[i].Key)
+			if err != nil {
+				return err
+			}
+			if ok || removeSession {
+				for _, event := range events {
+					n.RecordSessionEvent(event.t, &items[i], event.reason)
+				}
 			}
 
+			if err = n.RemoveSessionPeer(&items[i], reason); err != nil {
+				return err
+			}
+		}
+
+		if removeSession {
 			n.Database().Model(
 				&types.Session{},
 			).Where(
 				&types.Session{
-					ID: item.ID,
-				},
-			).Updates(
-				&types.Session{
-					Upload:   peers[i].Upload,
-					Download: peers[i].Download,
+					ID: items[i].ID,
 				},
+			).Unscoped().Delete(
+				&types.Session{},
 			)
+		}
 
-			var (
-				available = sdk.NewInt(item.Available)
-				consumed  = sdk.NewInt(peers[i].Upload + peers[i].Download)
-			)
+		if skipUpdate {
+			items = append(items[:i], items[i+1:]...)
+		}
+	}
 
-			if available.IsPositive() && consumed.GT(available) {
-				n.Log().Info("Peer allocation exceeded", "key", item.Key)
-				if err = n.RemovePeer(item.Key); err != nil {
-					return err
-				}
-			}
+	return n.UpdateSessions(items...)
+}
+
+// peerTraffic samples the counters of the peers for a while and returns the
+// rate of their combined traffic in bytes per second.
+func (n *Node) peerTraffic(ctx context.Context) (float64, error) {
+	sum := func() (v int64, err error) {
+		peers, err := n.Service().Peers()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, peer := range peers {
+			v += peer.Upload + peer.Download
+		}
+
+		return v, nil
+	}
+
+	before, err := sum()
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(speedTestSampleDuration):
+	}
+
+	after, err := sum()
+	if err != nil {
+		return 0, err
+	}
+
+	// Peers disconnecting in between take their counters with them
+	if after < before {
+		return 0, nil
+	}
+
+	return float64(after-before) / speedTestSampleDuration.Seconds(), nil
+}
+
+func (n *Node) speedTest(ctx context.Context) error {
+	if threshold := n.Config().SpeedTest.SkipThreshold; threshold > 0 {
+		rate, err := n.peerTraffic(ctx)
+		if err != nil {
+			return err
+		}
+
+		capacity := float64(n.Bandwidth().Sum().Int64())
+		if capacity > 0 && rate > threshold*capacity {
+			n.Log().Info("Skipping the speed test due to the peer traffic", "rate", rate,
+				"capacity", capacity)
+			return nil
+		}
+	}
+
+	n.Log().Info("Performing the internet speed test...")
+	result, err := bandwidth.Probe(ctx, n.Config().SpeedTest.BandwidthProbers()...)
+	if err != nil {
+		return err
+	}
+
+	n.Log().Info("Internet speed test result", "data", result)
+	if err = n.Config().SpeedTest.CheckMin(result.Upload, result.Download); err != nil {
+		n.Log().Error("Internet speed is below the minimum", "error", err)
+	}
+
+	n.UpdateBandwidth(result)
+	return nil
+}
+
+type balanceAlert struct {
+	Node      string `json:"node"`
+	Payer     string `json:"payer"`
+	Balance   string `json:"balance"`
+	Threshold string `json:"threshold"`
+	Estimate  string `json:"estimate"`
+	Runway    string `json:"runway"`
+}
+
+// checkBalance compares the balance of the fee payer against the fees that
+// would be paid over the runway at the rate of the recent transactions, or the
+// configured minimum when it is higher.
+func (n *Node) checkBalance(ctx context.Context) error {
+	payer := n.FeePayer()
+	balance, err := n.Client().QueryBalances(payer)
+	if err != nil {
+		return err
+	}
+
+	fees, err := n.FeesSince(time.Now().Add(-feeEstimateWindow), "")
+	if err != nil {
+		return err
+	}
+
+	metrics.FeePayerBalance.Reset()
+	for _, coin := range balance {
+		metrics.FeePayerBalance.WithLabelValues(coin.Denom).Set(sdk.NewDecFromInt(coin.Amount).MustFloat64())
+	}
+
+	var (
+		runway   = n.Config().Balance.Runway
+		estimate = sdk.NewCoins()
+	)
+
+	metrics.FeePayerRunway.Reset()
+	for _, coin := range fees {
+		v := sdk.NewDecFromInt(coin.Amount).MulInt64(int64(runway)).QuoInt64(int64(feeEstimateWindow))
+		estimate = estimate.Add(sdk.NewCoin(coin.Denom, v.Ceil().TruncateInt()))
+
+		seconds := sdk.NewDecFromInt(balance.AmountOf(coin.Denom)).
+			MulInt64(int64(feeEstimateWindow.Seconds())).QuoInt(coin.Amount)
+		metrics.FeePayerRunway.WithLabelValues(coin.Denom).Set(seconds.MustFloat64())
+	}
+
+	threshold := estimate
+	for _, coin := range n.Config().Balance.MinBalanceCoins() {
+		if v := threshold.AmountOf(coin.Denom); coin.Amount.GT(v) {
+			threshold = threshold.Add(sdk.NewCoin(coin.Denom, coin.Amount.Sub(v)))
+		}
+	}
+
+	if balance.IsAllGTE(threshold) {
+		n.Log().Debug("Balance of the fee payer is sufficient", "balance", balance,
+			"threshold", threshold)
+		return nil
+	}
+
+	n.Log().Error("Balance of the fee payer is low", "payer", payer, "balance", balance,
+		"threshold", threshold, "estimate", estimate, "runway", runway)
+
+	if url := n.Config().Balance.Webhook; url != "" {
+		alert := balanceAlert{
+			Node:      n.Address().String(),
+			Payer:     payer.String(),
+			Balance:   balance.String(),
+			Threshold: threshold.String(),
+			Estimate:  estimate.String(),
+			Runway:    runway.String(),
+		}
+
+		if err = utils.PostJSON(ctx, url, alert); err != nil {
+			n.Log().Error("failed to call the balance webhook", "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (n *Node) jobSetSessions(ctx context.Context) error {
+	n.Log().Info("Starting a job", "name", "set_sessions", "interval", n.IntervalSetSessions())
+
+	t := time.NewTicker(n.IntervalSetSessions())
+	defer t.Stop()
+
+	for {
+		start := time.Now()
+		err := n.setSessions()
+		metrics.ObserveJob("set_sessions", start, err)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
 		}
 	}
 }
 
-func (n *Node) jobUpdateStatus() error {
+func (n *Node) jobUpdateStatus(ctx context.Context) error {
 	n.Log().Info("Starting a job", "name", "update_status", "interval", n.IntervalUpdateStatus())
 
 	t := time.NewTicker(n.IntervalUpdateStatus())
-	for ; ; <-t.C {
-		if err := n.UpdateNodeStatus(); err != nil {
+	defer t.Stop()
+
+	for {
+		start := time.Now()
+		err := n.UpdateNodeStatus()
+		metrics.ObserveJob("update_status", start, err)
+		if err != nil {
 			return err
 		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
 	}
 }
 
-func (n *Node) jobUpdateSessions() error {
+func (n *Node) jobCheckRemotes(ctx context.Context) error {
+	n.Log().Info("Starting a job", "name", "check_remotes", "interval", remoteCheckInterval)
+
+	t := time.NewTicker(remoteCheckInterval)
+	defer t.Stop()
+
+	for {
+		start := time.Now()
+		n.Client().CheckRemotes(ctx)
+		metrics.ObserveJob("check_remotes", start, nil)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+func (n *Node) jobUpdateSessions(ctx context.Context) error {
 	n.Log().Info("Starting a job", "name", "update_sessions", "interval", n.IntervalUpdateSessions())
 
 	t := time.NewTicker(n.IntervalUpdateSessions())
-	for ; ; <-t.C {
-		var items []types.Session
-		n.Database().Model(
-			&types.Session{},
-		).Find(&items)
+	defer t.Stop()
 
-		count := len(items)
-		n.Log().Info("Validating the sessions", "count", count)
+	var reply chan<- error
+	for {
+		start := time.Now()
+		err := n.updateSessions()
+		metrics.ObserveJob("update_sessions", start, err)
+		if reply != nil {
+			reply <- err
+			reply = nil
+		}
+		if err != nil {
+			return err
+		}
 
-		for i := count - 1; i >= 0; i-- {
-			session, err := n.Client().QuerySession(items[i].ID)
-			if err != nil {
-				return err
-			}
-			if session == nil {
-				session = &sessiontypes.Session{
-					ID:             items[i].ID,
-					SubscriptionID: items[i].Subscription,
-					Bandwidth:      hubtypes.NewBandwidthFromInt64(items[i].Upload, items[i].Download),
-					Status:         hubtypes.StatusInactive,
-				}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		case reply = <-n.FlushRequests():
+			n.Log().Info("Flushing the sessions on request")
+			if err = n.setSessions(); err != nil {
+				n.Log().Error("failed to set the sessions", "error", err)
 			}
+		}
+	}
+}
 
-			subscription, err := n.Client().QuerySubscription(session.SubscriptionID)
-			if err != nil {
-				return err
-			}
-			if subscription == nil {
-				subscription = &subscriptiontypes.NodeSubscription{
-					BaseSubscription: &subscriptiontypes.BaseSubscription{
-						ID:     items[i].Subscription,
-						Status: hubtypes.StatusInactive,
-					},
-				}
-			}
+func (n *Node) jobSpeedTest(ctx context.Context) error {
+	n.Log().Info("Starting a job", "name", "speed_test", "interval", n.Config().SpeedTest.Interval)
 
-			var (
-				removePeer    = false
-				removeSession = false
-				skipUpdate    = false
-			)
+	t := time.NewTicker(n.Config().SpeedTest.Interval)
+	defer t.Stop()
 
-			if items[i].Upload == session.Bandwidth.Upload.Int64() {
-				skipUpdate = true
-				if items[i].CreatedAt.Before(session.StatusAt) {
-					removePeer = true
-				}
+	// The speed was measured while starting the node
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
 
-				n.Log().Info("Stale peer connection", "key", items[i].Key,
-					"created_at", items[i].CreatedAt, "status_at", session.StatusAt)
-			}
-			if !subscription.GetStatus().Equal(hubtypes.StatusActive) {
-				removePeer = true
-				if subscription.GetStatus().Equal(hubtypes.StatusInactive) {
-					removeSession, skipUpdate = true, true
-				}
+		start := time.Now()
+		err := n.speedTest(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
 
-				n.Log().Info("Invalid subscription status", "key", items[i].Key,
-					"id", subscription.GetID(), "status", subscription.GetStatus())
-			}
-			if !session.Status.Equal(hubtypes.StatusActive) {
-				removePeer = true
-				if session.Status.Equal(hubtypes.StatusInactive) {
-					removeSession, skipUpdate = true, true
-				}
+		metrics.ObserveJob("speed_test", start, err)
+		if err != nil {
+			return err
+		}
+	}
+}
 
-				n.Log().Info("Invalid session status", "key", items[i].Key,
-					"id", session.ID, "status", session.Status)
-			}
+func (n *Node) jobCheckBalance(ctx context.Context) error {
+	n.Log().Info("Starting a job", "name", "check_balance", "interval", n.Config().Balance.Interval)
 
-			if removePeer {
-				if err = n.RemovePeerIfExists(items[i].Key); err != nil {
-					return err
-				}
-			}
+	t := time.NewTicker(n.Config().Balance.Interval)
+	defer t.Stop()
 
-			if removeSession {
-				n.Database().Model(
-					&types.Session{},
-				).Where(
-					&types.Session{
-						ID: items[i].ID,
-					},
-				).Unscoped().Delete(
-					&types.Session{},
-				)
-			}
+	for {
+		start := time.Now()
+		err := n.checkBalance(ctx)
+		metrics.ObserveJob("check_balance", start, err)
+		if err != nil {
+			return err
+		}
 
-			if skipUpdate {
-				items = append(items[:i], items[i+1:]...)
-			}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
 		}
+	}
+}
 
-		if len(items) == 0 {
-			continue
+func (n *Node) jobUpdatePrices(ctx context.Context) error {
+	n.Log().Info("Starting a job", "name", "update_prices", "interval", n.Config().Pricing.Interval)
+
+	t := time.NewTicker(n.Config().Pricing.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
 		}
-		if err := n.UpdateSessions(items...); err != nil {
+
+		start := time.Now()
+		err := n.UpdatePrices()
+		metrics.ObserveJob("update_prices", start, err)
+		if err != nil {
 			return err
 		}
 	}
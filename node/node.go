@@ -1,12 +1,34 @@
 package node
 
 import (
+	stdcontext "context"
+	"os/signal"
 	"path"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/avast/retry-go/v4"
 
 	"github.com/sentinel-official/dvpn-node/context"
+	"github.com/sentinel-official/dvpn-node/metrics"
 	"github.com/sentinel-official/dvpn-node/utils"
 )
 
+const (
+	feeEstimateWindow       = 24 * time.Hour
+	jobHealthyDuration      = 10 * time.Minute
+	jobRestartDelay         = 5 * time.Second
+	jobRestartMaxDelay      = 5 * time.Minute
+	remoteCheckInterval     = 1 * time.Minute
+	speedTestSampleDuration = 10 * time.Second
+)
+
+type job struct {
+	name string
+	fn   func(ctx stdcontext.Context) error
+}
+
 type Node struct {
 	*context.Context
 }
@@ -18,6 +40,15 @@ func NewNode(ctx *context.Context) *Node {
 func (n *Node) Initialize() error {
 	n.Log().Info("Initializing...")
 
+	if n.Config().Pricing.Enable {
+		prices, err := n.EvaluatePrices()
+		if err != nil {
+			return err
+		}
+
+		n.SetDynamicPrices(prices)
+	}
+
 	result, err := n.Client().QueryNode(n.Address())
 	if err != nil {
 		return err
@@ -30,34 +61,172 @@ func (n *Node) Initialize() error {
 	return n.UpdateNodeInfo()
 }
 
-func (n *Node) Start(home string) error {
-	go func() {
-		if err := n.jobSetSessions(); err != nil {
-			panic(err)
-		}
-	}()
+func (n *Node) jobs() []job {
+	items := []job{
+		{name: "set_sessions", fn: n.jobSetSessions},
+		{name: "update_sessions", fn: n.jobUpdateSessions},
+		{name: "update_status", fn: n.jobUpdateStatus},
+	}
+
+	if n.Config().Balance.Enable {
+		items = append(items, job{name: "check_balance", fn: n.jobCheckBalance})
+	}
+	if n.Config().Pricing.Enable {
+		items = append(items, job{name: "update_prices", fn: n.jobUpdatePrices})
+	}
+	if len(n.Client().Remotes()) > 1 {
+		items = append(items, job{name: "check_remotes", fn: n.jobCheckRemotes})
+	}
+	if n.Config().SpeedTest.Enable {
+		items = append(items, job{name: "speed_test", fn: n.jobSpeedTest})
+	}
+
+	return items
+}
+
+// runJob restarts the job with a backoff whenever it fails, until the context
+// is cancelled. A job failing after it has run for the healthy duration starts
+// over with a fresh backoff.
+func (n *Node) runJob(ctx stdcontext.Context, j job) {
+	for ctx.Err() == nil {
+		var healthy error
+		_ = retry.Do(
+			func() error {
+				start := time.Now()
+				err := j.fn(ctx)
+				if err != nil && time.Since(start) >= jobHealthyDuration {
+					healthy = err
+					return retry.Unrecoverable(err)
+				}
 
-	go func() {
-		if err := n.jobUpdateSessions(); err != nil {
-			panic(err)
+				return err
+			},
+			retry.Attempts(0),
+			retry.Context(ctx),
+			retry.Delay(jobRestartDelay),
+			retry.MaxDelay(jobRestartMaxDelay),
+			retry.DelayType(retry.BackOffDelay),
+			retry.OnRetry(func(attempt uint, err error) {
+				n.Log().Error("job exited unexpectedly; restarting", "name", j.name,
+					"attempt", attempt, "error", err)
+			}),
+		)
+		if healthy == nil {
+			break
 		}
-	}()
 
-	go func() {
-		if err := n.jobUpdateStatus(); err != nil {
-			panic(err)
+		n.Log().Error("job exited unexpectedly; restarting", "name", j.name, "error", healthy)
+		select {
+		case <-ctx.Done():
+		case <-time.After(jobRestartDelay):
 		}
-	}()
+	}
+
+	n.Log().Info("Stopped the job", "name", j.name)
+}
+
+func (n *Node) Start(home string) error {
+	ctx, stop := signal.NotifyContext(stdcontext.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := stdcontext.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, j := range n.jobs() {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			n.runJob(ctx, j)
+		}(j)
+	}
+
+	if n.Config().Metrics.Enable {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			n.Log().Info("Serving the metrics", "listen_on", n.Config().Metrics.ListenOn)
+			if err := utils.ListenAndServe(ctx, n.Config().Metrics.ListenOn, metrics.Handler()); err != nil {
+				n.Log().Error("failed to serve the metrics", "error", err)
+			}
+		}()
+	}
 
 	var (
 		certFile = path.Join(home, "tls.crt")
 		keyFile  = path.Join(home, "tls.key")
 	)
 
-	return utils.ListenAndServeTLS(
+	if n.Config().Admin.Enable {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			n.Log().Info("Serving the admin API", "listen_on", n.Config().Admin.ListenOn)
+			err := utils.ListenAndServeTLSWithClientCA(
+				ctx,
+				n.Config().Admin.ListenOn,
+				certFile,
+				keyFile,
+				n.Config().Admin.ClientCAFile,
+				n.AdminHandler(),
+			)
+			if err != nil {
+				n.Log().Error("failed to serve the admin API", "error", err)
+			}
+		}()
+	}
+
+	err := utils.ListenAndServeTLS(
+		ctx,
 		n.ListenOn(),
 		certFile,
 		keyFile,
 		n.Handler(),
 	)
+	if err != nil {
+		n.Log().Error("failed to serve the API", "error", err)
+	}
+
+	n.Log().Info("Shutting down...")
+
+	cancel()
+	wg.Wait()
+
+	if e := n.shutdown(); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+func (n *Node) shutdown() (err error) {
+	n.Log().Info("Flushing the sessions...")
+	if e := n.setSessions(); e != nil {
+		n.Log().Error("failed to set the sessions", "error", e)
+		err = e
+	}
+	if e := n.updateSessions(); e != nil {
+		n.Log().Error("failed to update the sessions", "error", e)
+		err = e
+	}
+
+	n.Log().Info("Stopping the VPN service", "type", n.Service().Type())
+	if e := n.Service().Stop(); e != nil {
+		n.Log().Error("failed to stop the VPN service", "error", e)
+		err = e
+	}
+
+	n.Log().Info("Closing the database...")
+	db, e := n.Database().DB()
+	if e == nil {
+		e = db.Close()
+	}
+	if e != nil {
+		n.Log().Error("failed to close the database", "error", e)
+		err = e
+	}
+
+	return err
 }
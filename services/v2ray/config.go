@@ -6,7 +6,9 @@ import (
 
 var (
 	configTemplate = strings.TrimSpace(`
+{{- $api := not .Core.IsEmbedded -}}
 {
+    {{- if $api }}
     "api": {
         "services": [
             "HandlerService",
@@ -14,34 +16,47 @@ var (
         ],
         "tag": "api"
     },
+    {{- end }}
     "inbounds": [
+        {{- if $api }}
         {
-            "listen": "127.0.0.1",
-            "port": 23,
+            {{- if .Core.APIPort }}
+            "listen": "{{ .Core.APIListen }}",
+            "port": {{ .Core.APIPort }},
+            {{- else }}
+            "listen": "{{ .Core.APIListen }},0600",
+            {{- end }}
             "protocol": "dokodemo-door",
             "settings": {
-                "address": "127.0.0.1"
+                "address": "127.0.0.1"{{ if not .Core.APIPort }},
+                "network": "unix"{{ end }}
             },
             "tag": "api"
-        },
+        }
+        {{- end }}
+        {{- range $i, $v := .Inbounds }}{{ if or $i $api }},{{ end }}
         {
-            "port": "{{ .VMess.ListenPort }}",
-            "protocol": "vmess",
+            "port": "{{ .ListenPort }}",
+            "protocol": "{{ .Proxy }}",
+            "settings": {
+                "clients": []{{ if eq .Proxy.String "vless" }},
+                "decryption": "none"{{ end }}
+            },
             "streamSettings": {
-                "network": "{{ .VMess.Transport }}",
-                "security": "{{ .VMess.Security }}",
+                "network": "{{ .Transport }}",
+                "security": "{{ .Security }}",
                 "tlsSettings": {
                     "allowInsecure": true,
                     "certificates": [
                         {
-                            "certificateFile": "{{ .VMess.TLSCertPath }}",
-                            "keyFile": "{{ .VMess.TLSKeyPath }}"
+                            "certificateFile": "{{ .TLSCertPath }}",
+                            "keyFile": "{{ .TLSKeyPath }}"
                         }
                     ]
                 }
             },
-            "tag": "vmess"
-        }
+            "tag": "{{ .Proxy.Tag }}"
+        }{{ end }}
     ],
     "log": {
         "access": "none",
@@ -61,6 +76,7 @@ var (
             }
         }
     },
+    {{- if $api }}
     "routing": {
         "rules": [
             {
@@ -72,6 +88,7 @@ var (
             }
         ]
     },
+    {{- end }}
     "stats": {},
     "transport": {
         "dsSettings": {},
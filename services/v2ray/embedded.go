@@ -0,0 +1,87 @@
+package v2ray
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+
+	"github.com/v2fly/v2ray-core/v5"
+	"github.com/v2fly/v2ray-core/v5/features/inbound"
+	"github.com/v2fly/v2ray-core/v5/features/stats"
+	_ "github.com/v2fly/v2ray-core/v5/main/distro/all"
+)
+
+var (
+	_ backend = (*embeddedBackend)(nil)
+)
+
+// embeddedBackend runs v2ray-core as an instance inside the node process and
+// controls it through its feature interfaces, without an API inbound.
+type embeddedBackend struct {
+	config   []byte
+	instance *core.Instance
+}
+
+func newEmbeddedBackend(config []byte) *embeddedBackend {
+	return &embeddedBackend{
+		config: config,
+	}
+}
+
+func (b *embeddedBackend) start() error {
+	if err := os.Setenv("V2RAY_VMESS_AEAD_FORCED", "false"); err != nil {
+		return err
+	}
+
+	config, err := core.LoadConfig("json", bytes.NewReader(b.config))
+	if err != nil {
+		return err
+	}
+
+	instance, err := core.New(config)
+	if err != nil {
+		return err
+	}
+	if err = instance.Start(); err != nil {
+		_ = instance.Close()
+		return err
+	}
+
+	b.instance = instance
+	return nil
+}
+
+func (b *embeddedBackend) stop() error {
+	if b.instance == nil {
+		return errors.New("instance is not started")
+	}
+
+	return b.instance.Close()
+}
+
+func (b *embeddedBackend) alterInbound(tag string, op inboundOperation) error {
+	ctx := context.TODO()
+
+	manager := b.instance.GetFeature(inbound.ManagerType()).(inbound.Manager)
+	handler, err := manager.GetHandler(ctx, tag)
+	if err != nil {
+		return err
+	}
+
+	return op.ApplyInbound(ctx, handler)
+}
+
+func (b *embeddedBackend) stats(names ...string) (values []int64, err error) {
+	manager := b.instance.GetFeature(stats.ManagerType()).(stats.Manager)
+	for _, name := range names {
+		var value int64
+		if counter := manager.GetCounter(name); counter != nil {
+			value = counter.Value()
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,319 @@
+package v2ray
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	proxymancommand "github.com/v2fly/v2ray-core/v5/app/proxyman/command"
+	statscommand "github.com/v2fly/v2ray-core/v5/app/stats/command"
+	"github.com/v2fly/v2ray-core/v5/common/serial"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	apiTimeout        = 10 * time.Second
+	readyTimeout      = 15 * time.Second
+	readyPollInterval = 250 * time.Millisecond
+	restartDelay      = 1 * time.Second
+	restartMaxDelay   = 1 * time.Minute
+	stopTimeout       = 10 * time.Second
+)
+
+var (
+	_ backend = (*processBackend)(nil)
+)
+
+// apiEndpoint is the network and the address the API inbound of the binary
+// listens on.
+type apiEndpoint struct {
+	network string
+	address string
+}
+
+// target returns the address of the endpoint for dialling with gRPC.
+func (e apiEndpoint) target() string {
+	if e.network == "unix" {
+		return "unix://" + e.address
+	}
+
+	return e.address
+}
+
+// process is a running v2ray process, whose done channel is closed once it
+// exits with the error of err.
+type process struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// processBackend runs the v2ray binary as a child process, restarting it when
+// it exits, and controls it through the gRPC services of its API inbound over
+// a connection kept for the lifetime of the backend.
+type processBackend struct {
+	api        apiEndpoint
+	configPath string
+	log        tmlog.Logger
+	restore    func() error
+
+	cancel context.CancelFunc
+	conn   *grpc.ClientConn
+	done   chan struct{}
+	mutex  sync.Mutex
+	proc   *process
+}
+
+func newProcessBackend(api apiEndpoint, configPath string, log tmlog.Logger, restore func() error) *processBackend {
+	return &processBackend{
+		api:        api,
+		configPath: configPath,
+		log:        log,
+		restore:    restore,
+	}
+}
+
+func (b *processBackend) newProcess() (*process, error) {
+	// The socket of an exited process is left behind and must be removed
+	// before the API inbound can listen on it again.
+	if b.api.network == "unix" {
+		if err := os.Remove(b.api.address); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command("v2ray", strings.Split(
+		fmt.Sprintf("run --config %s", b.configPath), " ")...)
+
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "V2RAY_VMESS_AEAD_FORCED=false")
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &process{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// waitReady waits for the API inbound of the process to accept connections.
+func (b *processBackend) waitReady(p *process) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		conn, err := net.DialTimeout(b.api.network, b.api.address, readyPollInterval)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("api is not ready within %s; %w", readyTimeout, err)
+		}
+
+		select {
+		case <-p.done:
+			return fmt.Errorf("process exited; %v", p.err)
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// terminate asks the process to exit and kills it after the stop timeout.
+func (b *processBackend) terminate(p *process) error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return p.cmd.Process.Kill()
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(stopTimeout):
+		b.log.Error("v2ray process did not exit in time; killing", "timeout", stopTimeout)
+	}
+
+	if err := p.cmd.Process.Kill(); err != nil {
+		return err
+	}
+
+	<-p.done
+	return nil
+}
+
+func (b *processBackend) startProcess() (*process, error) {
+	p, err := b.newProcess()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.waitReady(p); err != nil {
+		_ = b.terminate(p)
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	b.proc = p
+	b.mutex.Unlock()
+
+	// The connection may be waiting to reconnect to the exited process.
+	if b.conn != nil {
+		b.conn.ResetConnectBackoff()
+	}
+
+	return p, nil
+}
+
+// restart starts a new process and adds the peers back to it.
+func (b *processBackend) restart() (*process, error) {
+	p, err := b.startProcess()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.restore(); err != nil {
+		_ = b.terminate(p)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// supervise restarts the process with a backoff whenever it exits, until the
+// context is cancelled.
+func (b *processBackend) supervise(ctx context.Context, p *process) {
+	defer close(b.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+		}
+
+		b.log.Error("v2ray process exited unexpectedly; restarting", "error", p.err)
+		err := retry.Do(
+			func() (err error) {
+				p, err = b.restart()
+				return err
+			},
+			retry.Attempts(0),
+			retry.Context(ctx),
+			retry.Delay(restartDelay),
+			retry.MaxDelay(restartMaxDelay),
+			retry.DelayType(retry.BackOffDelay),
+			retry.LastErrorOnly(true),
+			retry.OnRetry(func(attempt uint, err error) {
+				b.log.Error("failed to restart the v2ray process", "attempt", attempt, "error", err)
+			}),
+		)
+		if err != nil {
+			return
+		}
+
+		b.log.Info("Restarted the v2ray process")
+	}
+}
+
+func (b *processBackend) start() error {
+	p, err := b.startProcess()
+	if err != nil {
+		return err
+	}
+
+	b.conn, err = grpc.Dial(
+		b.api.target(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		_ = b.terminate(p)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+
+	go b.supervise(ctx, p)
+	return nil
+}
+
+func (b *processBackend) stop() error {
+	if b.cancel == nil {
+		return errors.New("process is not started")
+	}
+
+	b.cancel()
+	<-b.done
+
+	b.mutex.Lock()
+	p := b.proc
+	b.mutex.Unlock()
+
+	if err := b.conn.Close(); err != nil {
+		b.log.Error("failed to close the api connection", "error", err)
+	}
+
+	return b.terminate(p)
+}
+
+func (b *processBackend) alterInbound(tag string, op inboundOperation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	req := &proxymancommand.AlterInboundRequest{
+		Tag:       tag,
+		Operation: serial.ToTypedMessage(op),
+	}
+
+	client := proxymancommand.NewHandlerServiceClient(b.conn)
+	_, err := client.AlterInbound(ctx, req, grpc.WaitForReady(true))
+	return err
+}
+
+func (b *processBackend) stats(names ...string) (values []int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	client := statscommand.NewStatsServiceClient(b.conn)
+	for _, name := range names {
+		req := &statscommand.GetStatsRequest{
+			Reset_: false,
+			Name:   name,
+		}
+
+		res, err := client.GetStats(ctx, req, grpc.WaitForReady(true))
+		if err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				return nil, err
+			}
+		}
+
+		values = append(values, res.GetStat().GetValue())
+	}
+
+	return values, nil
+}
@@ -3,7 +3,9 @@ package types
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -15,7 +17,44 @@ import (
 
 var (
 	ct = strings.TrimSpace(`
+[core]
+# Address of the API used to control the external binary; a unix socket such as
+# "unix:v2ray.sock" relative to the home directory, or a loopback host:port
+api_address = "{{ .Core.APIAddress }}"
+
+# Run the v2ray binary as a child process (external) or v2ray-core inside the node (embedded)
+mode = "{{ .Core.Mode }}"
+
+[trojan]
+# Enable the Trojan inbound, which requires TLS
+enable = {{ .Trojan.Enable }}
+
+# Port number to accept the incoming connections
+listen_port = {{ .Trojan.ListenPort }}
+
+# Enable or disable TLS for secure connections
+tls = {{ .Trojan.TLS }}
+
+# Name of the transport protocol
+transport = "{{ .Trojan.Transport }}"
+
+[vless]
+# Enable the VLESS inbound (XTLS and REALITY are not supported by the core)
+enable = {{ .VLess.Enable }}
+
+# Port number to accept the incoming connections
+listen_port = {{ .VLess.ListenPort }}
+
+# Enable or disable TLS for secure connections
+tls = {{ .VLess.TLS }}
+
+# Name of the transport protocol
+transport = "{{ .VLess.Transport }}"
+
 [vmess]
+# Enable the VMess inbound
+enable = {{ .VMess.Enable }}
+
 # Port number to accept the incoming connections
 listen_port = {{ .VMess.ListenPort }}
 
@@ -36,21 +75,79 @@ transport = "{{ .VMess.Transport }}"
 	}()
 )
 
-type VMessConfig struct {
+const (
+	ModeEmbedded = "embedded"
+	ModeExternal = "external"
+)
+
+type CoreConfig struct {
+	APIListen string `json:"api_listen"`
+	APIPort   uint16 `json:"api_port"`
+
+	APIAddress string `json:"api_address" mapstructure:"api_address"`
+	Mode       string `json:"mode" mapstructure:"mode"`
+}
+
+func NewCoreConfig() *CoreConfig {
+	return &CoreConfig{}
+}
+
+func (c *CoreConfig) WithDefaultValues() *CoreConfig {
+	c.APIAddress = DefaultAPIAddress
+	c.Mode = ModeExternal
+
+	return c
+}
+
+func (c *CoreConfig) Validate() error {
+	if strings.HasPrefix(c.APIAddress, APIUnixPrefix) {
+		if strings.TrimPrefix(c.APIAddress, APIUnixPrefix) == "" {
+			return errors.New("api_address cannot have an empty socket path")
+		}
+	} else if c.APIAddress != "" {
+		host, port, err := net.SplitHostPort(c.APIAddress)
+		if err != nil {
+			return errors.Wrapf(err, "invalid api_address %s", c.APIAddress)
+		}
+		// The API serves the handler and stats services without any
+		// authentication, so it must not be reachable from other hosts.
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("host %s of api_address must be a loopback address", host)
+		}
+		if v, err := strconv.ParseUint(port, 10, 16); err != nil || v == 0 {
+			return fmt.Errorf("invalid port %s of api_address", port)
+		}
+	}
+	if c.Mode != ModeEmbedded && c.Mode != ModeExternal {
+		return fmt.Errorf("invalid mode %s; expected one of %s or %s", c.Mode, ModeEmbedded, ModeExternal)
+	}
+
+	return nil
+}
+
+// IsEmbedded reports whether v2ray-core runs inside the node process, without
+// the API inbound used to control the external binary.
+func (c *CoreConfig) IsEmbedded() bool {
+	return c.Mode == ModeEmbedded
+}
+
+type InboundConfig struct {
 	Security    string `json:"security"`
 	TLSCertPath string `json:"tls_cert_path"`
 	TLSKeyPath  string `json:"tls_key_path"`
 
+	Enable     bool   `json:"enable" mapstructure:"enable"`
 	ListenPort uint16 `json:"listen_port" mapstructure:"listen_port"`
 	TLS        bool   `json:"tls" mapstructure:"tls"`
 	Transport  string `json:"transport" mapstructure:"transport"`
 }
 
-func NewVMessConfig() *VMessConfig {
-	return &VMessConfig{}
+func NewInboundConfig() *InboundConfig {
+	return &InboundConfig{}
 }
 
-func (c *VMessConfig) WithDefaultValues() *VMessConfig {
+func (c *InboundConfig) WithDefaultValues() *InboundConfig {
+	c.Enable = false
 	c.ListenPort = utils.RandomPort()
 	c.TLS = false
 	c.Transport = "grpc"
@@ -58,7 +155,10 @@ func (c *VMessConfig) WithDefaultValues() *VMessConfig {
 	return c
 }
 
-func (c *VMessConfig) Validate() error {
+func (c *InboundConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
 	if c.ListenPort == 0 {
 		return errors.New("listen_port cannot be zero")
 	}
@@ -74,30 +174,91 @@ func (c *VMessConfig) Validate() error {
 	return nil
 }
 
+// Inbound is an enabled inbound along with the proxy it serves.
+type Inbound struct {
+	*InboundConfig
+	Proxy Proxy
+}
+
 type Config struct {
-	VMess *VMessConfig `json:"vmess" mapstructure:"vmess"`
+	Core   *CoreConfig    `json:"core" mapstructure:"core"`
+	Trojan *InboundConfig `json:"trojan" mapstructure:"trojan"`
+	VLess  *InboundConfig `json:"vless" mapstructure:"vless"`
+	VMess  *InboundConfig `json:"vmess" mapstructure:"vmess"`
 }
 
 func NewConfig() *Config {
 	return &Config{
-		VMess: NewVMessConfig(),
+		Core:   NewCoreConfig(),
+		Trojan: NewInboundConfig(),
+		VLess:  NewInboundConfig(),
+		VMess:  NewInboundConfig(),
 	}
 }
 
 func (c *Config) Validate() error {
+	if err := c.Core.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section core")
+	}
+	if err := c.Trojan.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section trojan")
+	}
+	if c.Trojan.Enable && !c.Trojan.TLS {
+		return errors.Wrapf(errors.New("tls must be enabled"), "invalid section trojan")
+	}
+	if err := c.VLess.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section vless")
+	}
 	if err := c.VMess.Validate(); err != nil {
 		return errors.Wrapf(err, "invalid section vmess")
 	}
 
+	items := c.Inbounds()
+	if len(items) == 0 {
+		return errors.New("at least one inbound must be enabled")
+	}
+
+	ports := make(map[uint16]string)
+	for _, item := range items {
+		if v, ok := ports[item.ListenPort]; ok {
+			return fmt.Errorf("listen_port %d of %s is already used by %s", item.ListenPort, item.Proxy, v)
+		}
+
+		ports[item.ListenPort] = item.Proxy.String()
+	}
+
 	return nil
 }
 
 func (c *Config) WithDefaultValues() *Config {
+	c.Core = c.Core.WithDefaultValues()
+
+	c.Trojan = c.Trojan.WithDefaultValues()
+	c.Trojan.TLS = true
+
+	c.VLess = c.VLess.WithDefaultValues()
+
 	c.VMess = c.VMess.WithDefaultValues()
+	c.VMess.Enable = true
 
 	return c
 }
 
+// Inbounds returns the enabled inbounds in the order of their proxy.
+func (c *Config) Inbounds() (items []Inbound) {
+	for _, item := range []Inbound{
+		{InboundConfig: c.VMess, Proxy: ProxyVMess},
+		{InboundConfig: c.VLess, Proxy: ProxyVLess},
+		{InboundConfig: c.Trojan, Proxy: ProxyTrojan},
+	} {
+		if item.Enable {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
 func (c *Config) SaveToPath(path string) error {
 	var buf bytes.Buffer
 	if err := t.Execute(&buf, c); err != nil {
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestCoreConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiAddress string
+		wantErr    bool
+	}{
+		{"empty", "", false},
+		{"unix socket", "unix:v2ray.sock", false},
+		{"unix socket without path", "unix:", true},
+		{"loopback ipv4", "127.0.0.1:10085", false},
+		{"loopback ipv6", "[::1]:10085", false},
+		{"localhost", "localhost:10085", false},
+		{"unspecified ipv4", "0.0.0.0:10085", true},
+		{"unspecified ipv6", "[::]:10085", true},
+		{"empty host", ":10085", true},
+		{"public address", "203.0.113.1:10085", true},
+		{"domain", "example.com:10085", true},
+		{"zero port", "127.0.0.1:0", true},
+		{"missing port", "127.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCoreConfig().WithDefaultValues()
+			c.APIAddress = tt.apiAddress
+
+			err := c.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,13 @@
 package types
 
 const (
-	Type           = 2
-	ConfigFileName = "v2ray.toml"
+	Type                  = 2
+	ConfigFileName        = "v2ray.toml"
+	RuntimeConfigFileName = "v2ray.json"
+
+	APIUnixPrefix = "unix:"
+
+	// DefaultAPIAddress is a socket under the home, which cannot be taken by
+	// another process between the restarts of the binary like a port can.
+	DefaultAPIAddress = APIUnixPrefix + "v2ray.sock"
 )
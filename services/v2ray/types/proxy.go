@@ -4,12 +4,23 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common/protocol"
 	"github.com/v2fly/v2ray-core/v5/common/serial"
 	"github.com/v2fly/v2ray-core/v5/common/uuid"
+	"github.com/v2fly/v2ray-core/v5/proxy/trojan"
+	"github.com/v2fly/v2ray-core/v5/proxy/vless"
 	"github.com/v2fly/v2ray-core/v5/proxy/vmess"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
+// Proxy is the protocol of an inbound. Shadowsocks is not offered, as the
+// v2fly core implements Shadowsocks-2022 only as an outbound, and its legacy
+// Shadowsocks inbound holds a single user which cannot be altered per session.
 type Proxy byte
 
+const (
+	ProxyVMess  Proxy = 0x01
+	ProxyVLess  Proxy = 0x02
+	ProxyTrojan Proxy = 0x03
+)
+
 func (p Proxy) Byte() byte {
 	return byte(p)
 }
@@ -23,17 +34,21 @@ func (p Proxy) Tag() string {
 }
 
 func (p Proxy) String() string {
-	switch p.Byte() {
-	case 0x01:
+	switch p {
+	case ProxyVMess:
 		return "vmess"
+	case ProxyVLess:
+		return "vless"
+	case ProxyTrojan:
+		return "trojan"
 	default:
 		return ""
 	}
 }
 
 func (p Proxy) Account(uid uuid.UUID) *anypb.Any {
-	switch p.Byte() {
-	case 0x01:
+	switch p {
+	case ProxyVMess:
 		return serial.ToTypedMessage(
 			&vmess.Account{
 				Id:      uid.String(),
@@ -44,6 +59,19 @@ func (p Proxy) Account(uid uuid.UUID) *anypb.Any {
 				TestsEnabled: "",
 			},
 		)
+	case ProxyVLess:
+		return serial.ToTypedMessage(
+			&vless.Account{
+				Id:         uid.String(),
+				Encryption: "none",
+			},
+		)
+	case ProxyTrojan:
+		return serial.ToTypedMessage(
+			&trojan.Account{
+				Password: uid.String(),
+			},
+		)
 	default:
 		return nil
 	}
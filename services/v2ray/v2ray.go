@@ -2,25 +2,23 @@ package v2ray
 
 import (
 	"bytes"
-	"context"
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	tmlog "github.com/tendermint/tendermint/libs/log"
 	proxymancommand "github.com/v2fly/v2ray-core/v5/app/proxyman/command"
-	statscommand "github.com/v2fly/v2ray-core/v5/app/stats/command"
 	"github.com/v2fly/v2ray-core/v5/common/protocol"
-	"github.com/v2fly/v2ray-core/v5/common/serial"
 	"github.com/v2fly/v2ray-core/v5/common/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	v2raytypes "github.com/sentinel-official/dvpn-node/services/v2ray/types"
 	"github.com/sentinel-official/dvpn-node/types"
@@ -28,31 +26,74 @@ import (
 )
 
 const (
-	InfoLen = 2 + 1 + 1
+	InfoLen        = 2 + 1 + 1
+	InboundInfoLen = 1 + 2 + 1 + 1
 )
 
 var (
 	_ types.Service = (*V2Ray)(nil)
 )
 
+// inboundOperation is an operation on the users of an inbound, which is sent
+// to the API of the external binary or applied to the embedded instance.
+type inboundOperation interface {
+	proto.Message
+	proxymancommand.InboundOperation
+}
+
+// backend runs v2ray-core and alters its inbounds and reads its stats.
+type backend interface {
+	start() error
+	stop() error
+	alterInbound(tag string, op inboundOperation) error
+	stats(names ...string) ([]int64, error)
+}
+
 type V2Ray struct {
-	info   []byte
-	cmd    *exec.Cmd
-	config *v2raytypes.Config
-	peers  *v2raytypes.Peers
+	info    []byte
+	backend backend
+	config  *v2raytypes.Config
+	log     tmlog.Logger
+	peers   *v2raytypes.Peers
 }
 
 func NewV2Ray() *V2Ray {
 	return &V2Ray{
 		info:   make([]byte, InfoLen),
-		cmd:    nil,
 		config: v2raytypes.NewConfig(),
+		log:    tmlog.NewNopLogger(),
 		peers:  v2raytypes.NewPeers(),
 	}
 }
 
-func (s *V2Ray) configFilePath() string {
-	return filepath.Join(os.TempDir(), "v2ray_config.json")
+func (s *V2Ray) WithLogger(v tmlog.Logger) *V2Ray {
+	s.log = v
+	return s
+}
+
+// apiEndpoint returns the endpoint of the api_address, which is the default
+// socket when it is empty, resolving a relative socket path against the home.
+func (s *V2Ray) apiEndpoint(home string) (apiEndpoint, error) {
+	address := s.config.Core.APIAddress
+	if address == "" {
+		address = v2raytypes.DefaultAPIAddress
+	}
+
+	if strings.HasPrefix(address, v2raytypes.APIUnixPrefix) {
+		path := strings.TrimPrefix(address, v2raytypes.APIUnixPrefix)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(home, path)
+		}
+
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return apiEndpoint{}, err
+		}
+
+		return apiEndpoint{network: "unix", address: path}, nil
+	}
+
+	return apiEndpoint{network: "tcp", address: address}, nil
 }
 
 func (s *V2Ray) Type() uint64 {
@@ -75,11 +116,37 @@ func (s *V2Ray) Init(home string) (err error) {
 		return err
 	}
 
-	if s.config.VMess.TLS {
-		s.config.VMess.Security = "tls"
+	for _, item := range s.config.Inbounds() {
+		if item.TLS {
+			item.Security = "tls"
+		}
+		item.TLSCertPath = filepath.Join(home, "tls.crt")
+		item.TLSKeyPath = filepath.Join(home, "tls.key")
+	}
+
+	var api apiEndpoint
+	if !s.config.Core.IsEmbedded() {
+		api, err = s.apiEndpoint(home)
+		if err != nil {
+			return err
+		}
+
+		if api.network == "unix" {
+			s.config.Core.APIListen = api.address
+		} else {
+			host, port, err := net.SplitHostPort(api.address)
+			if err != nil {
+				return err
+			}
+
+			v, err := strconv.ParseUint(port, 10, 16)
+			if err != nil {
+				return err
+			}
+
+			s.config.Core.APIListen, s.config.Core.APIPort = host, uint16(v)
+		}
 	}
-	s.config.VMess.TLSCertPath = filepath.Join(home, "tls.crt")
-	s.config.VMess.TLSKeyPath = filepath.Join(home, "tls.key")
 
 	t, err := template.New("v2ray_json").Parse(configTemplate)
 	if err != nil {
@@ -90,117 +157,144 @@ func (s *V2Ray) Init(home string) (err error) {
 	if err = t.Execute(&buf, s.config); err != nil {
 		return err
 	}
-	if err = os.WriteFile(s.configFilePath(), buf.Bytes(), 0600); err != nil {
-		return err
-	}
 
-	binary.BigEndian.PutUint16(s.info[0:], s.config.VMess.ListenPort)
-	transport := v2raytypes.NewTransportFromString(s.config.VMess.Transport)
-	s.info[2] = transport.Byte()
-	s.info[3] = utils.ByteFromBool(s.config.VMess.TLS)
+	if s.config.Core.IsEmbedded() {
+		s.backend = newEmbeddedBackend(buf.Bytes())
+	} else {
+		path := filepath.Join(home, v2raytypes.RuntimeConfigFileName)
+		if err = os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+			return err
+		}
+
+		s.backend = newProcessBackend(api, path, s.log, s.restorePeers)
+	}
 
+	s.info = s.newInfo()
 	return nil
 }
 
-func (s *V2Ray) Start() error {
-	s.cmd = exec.Command("v2ray", strings.Split(
-		fmt.Sprintf("run --config %s", s.configFilePath()), " ")...)
+// newInfo returns the port, transport and TLS of the vmess inbound as before,
+// which are zero when it is disabled, followed by the proxy, port, transport
+// and TLS of every enabled inbound.
+func (s *V2Ray) newInfo() []byte {
+	var (
+		inbounds = s.config.Inbounds()
+		info     = make([]byte, InfoLen, InfoLen+InboundInfoLen*len(inbounds))
+	)
 
-	s.cmd.Env = os.Environ()
-	s.cmd.Env = append(s.cmd.Env, "V2RAY_VMESS_AEAD_FORCED=false")
+	if s.config.VMess.Enable {
+		binary.BigEndian.PutUint16(info[0:], s.config.VMess.ListenPort)
+		info[2] = v2raytypes.NewTransportFromString(s.config.VMess.Transport).Byte()
+		info[3] = utils.ByteFromBool(s.config.VMess.TLS)
+	}
 
-	s.cmd.Stdout = os.Stdout
-	s.cmd.Stderr = os.Stderr
+	for _, item := range inbounds {
+		buf := make([]byte, InboundInfoLen)
+		buf[0] = item.Proxy.Byte()
+		binary.BigEndian.PutUint16(buf[1:], item.ListenPort)
+		buf[3] = v2raytypes.NewTransportFromString(item.Transport).Byte()
+		buf[4] = utils.ByteFromBool(item.TLS)
 
-	return s.cmd.Start()
-}
-
-func (s *V2Ray) Stop() error {
-	if s.cmd == nil {
-		return errors.New("command is nil")
+		info = append(info, buf...)
 	}
 
-	return s.cmd.Process.Kill()
+	return info
 }
 
-func (s *V2Ray) clientConn() (*grpc.ClientConn, error) {
-	target := "127.0.0.1:23"
-	return grpc.Dial(
-		target,
-		grpc.WithBlock(),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-}
+// inbound returns the enabled inbound serving the proxy.
+func (s *V2Ray) inbound(proxy v2raytypes.Proxy) (v2raytypes.Inbound, error) {
+	if !proxy.IsValid() {
+		return v2raytypes.Inbound{}, fmt.Errorf("proxy %d is not supported", proxy.Byte())
+	}
 
-func (s *V2Ray) handlerServiceClient() (*grpc.ClientConn, proxymancommand.HandlerServiceClient, error) {
-	conn, err := s.clientConn()
-	if err != nil {
-		return nil, nil, err
+	for _, item := range s.config.Inbounds() {
+		if item.Proxy == proxy {
+			return item, nil
+		}
 	}
 
-	client := proxymancommand.NewHandlerServiceClient(conn)
-	return conn, client, nil
+	return v2raytypes.Inbound{}, fmt.Errorf("proxy %s is not enabled", proxy)
 }
 
-func (s *V2Ray) statsServiceClient() (*grpc.ClientConn, statscommand.StatsServiceClient, error) {
-	conn, err := s.clientConn()
-	if err != nil {
-		return nil, nil, err
-	}
+// restorePeers adds the users of the peers back to a restarted backend.
+func (s *V2Ray) restorePeers() error {
+	return s.peers.Iterate(
+		func(key string, _ v2raytypes.Peer) (bool, error) {
+			data, err := base64.StdEncoding.DecodeString(key)
+			if err != nil {
+				return false, err
+			}
 
-	client := statscommand.NewStatsServiceClient(conn)
-	return conn, client, nil
+			return false, s.addUser(data)
+		},
+	)
 }
 
-func (s *V2Ray) AddPeer(data []byte) (result []byte, err error) {
-	if len(data) != 1+16 {
-		return nil, errors.New("data length must be 17 bytes")
-	}
+func (s *V2Ray) Start() error {
+	return s.backend.start()
+}
 
-	conn, client, err := s.handlerServiceClient()
+func (s *V2Ray) Stop() error {
+	return s.backend.stop()
+}
+
+// addUser adds the user of the peer to the inbound of its proxy.
+func (s *V2Ray) addUser(data []byte) error {
+	inbound, err := s.inbound(v2raytypes.Proxy(data[0]))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer func() {
-		if err = conn.Close(); err != nil {
-			panic(err)
-		}
-	}()
-
 	var (
 		email  = base64.StdEncoding.EncodeToString(data)
-		proxy  = v2raytypes.Proxy(data[0])
 		uid, _ = uuid.ParseBytes(data[1:])
 	)
 
-	req := &proxymancommand.AlterInboundRequest{
-		Tag: proxy.Tag(),
-		Operation: serial.ToTypedMessage(
-			&proxymancommand.AddUserOperation{
-				User: &protocol.User{
-					Level:   0,
-					Email:   email,
-					Account: proxy.Account(uid),
-				},
+	return s.backend.alterInbound(
+		inbound.Proxy.Tag(),
+		&proxymancommand.AddUserOperation{
+			User: &protocol.User{
+				Level:   0,
+				Email:   email,
+				Account: inbound.Proxy.Account(uid),
 			},
-		),
+		},
+	)
+}
+
+func (s *V2Ray) AddPeer(data []byte) (result []byte, err error) {
+	if len(data) != 1+16 {
+		return nil, errors.New("data length must be 17 bytes")
 	}
 
-	_, err = client.AlterInbound(context.TODO(), req)
-	if err != nil {
+	if err = s.addUser(data); err != nil {
 		return nil, err
 	}
 
 	s.peers.Put(
 		v2raytypes.Peer{
-			Email: email,
+			Email: base64.StdEncoding.EncodeToString(data),
 		},
 	)
 
 	return result, nil
 }
 
+func (s *V2Ray) GetPeer(data []byte) (types.Peer, bool) {
+	var (
+		email = base64.StdEncoding.EncodeToString(data)
+		peer  = s.peers.Get(email)
+	)
+
+	if peer.Empty() {
+		return types.Peer{}, false
+	}
+
+	return types.Peer{
+		Key: peer.Email,
+	}, true
+}
+
 func (s *V2Ray) HasPeer(data []byte) bool {
 	var (
 		email = base64.StdEncoding.EncodeToString(data)
@@ -215,32 +309,17 @@ func (s *V2Ray) RemovePeer(data []byte) error {
 		return errors.New("data length must be 17 bytes")
 	}
 
-	conn, client, err := s.handlerServiceClient()
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if err = conn.Close(); err != nil {
-			panic(err)
-		}
-	}()
-
 	var (
 		email = base64.StdEncoding.EncodeToString(data)
 		proxy = v2raytypes.Proxy(data[0])
 	)
 
-	req := &proxymancommand.AlterInboundRequest{
-		Tag: proxy.Tag(),
-		Operation: serial.ToTypedMessage(
-			&proxymancommand.RemoveUserOperation{
-				Email: email,
-			},
-		),
-	}
-
-	_, err = client.AlterInbound(context.TODO(), req)
+	err := s.backend.alterInbound(
+		proxy.Tag(),
+		&proxymancommand.RemoveUserOperation{
+			Email: email,
+		},
+	)
 	if err != nil {
 		if !strings.Contains(err.Error(), "not found") {
 			return err
@@ -252,70 +331,61 @@ func (s *V2Ray) RemovePeer(data []byte) error {
 	return nil
 }
 
-func (s *V2Ray) Peers() (items []types.Peer, err error) {
-	conn, client, err := s.statsServiceClient()
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if err = conn.Close(); err != nil {
-			panic(err)
+func (s *V2Ray) RestorePeers(items []types.Peer) error {
+	for _, item := range items {
+		data, err := base64.StdEncoding.DecodeString(item.Key)
+		if err != nil {
+			return err
+		}
+		if s.HasPeer(data) {
+			continue
 		}
-	}()
-
-	err = s.peers.Iterate(
-		func(key string, _ v2raytypes.Peer) (bool, error) {
-			req := &statscommand.GetStatsRequest{
-				Reset_: false,
-				Name:   fmt.Sprintf("user>>>%s>>>traffic>>>uplink", key),
-			}
-
-			res, err := client.GetStats(context.TODO(), req)
-			if err != nil {
-				if !strings.Contains(err.Error(), "not found") {
-					return false, err
-				}
-			}
 
-			upLink := res.GetStat()
-			if upLink == nil {
-				upLink = &statscommand.Stat{}
-			}
+		if _, err = s.AddPeer(data); err != nil {
+			return err
+		}
+	}
 
-			req = &statscommand.GetStatsRequest{
-				Reset_: false,
-				Name:   fmt.Sprintf("user>>>%s>>>traffic>>>downlink", key),
-			}
+	return nil
+}
 
-			res, err = client.GetStats(context.TODO(), req)
-			if err != nil {
-				if !strings.Contains(err.Error(), "not found") {
-					return false, err
-				}
-			}
+// SetPeerRate only accepts unlimited rates, as the policy levels of V2Ray
+// carry timeouts and buffer sizes but no bandwidth limits.
+func (s *V2Ray) SetPeerRate(_ []byte, download, upload int64) error {
+	if download > 0 || upload > 0 {
+		return errors.New("rate limiting is not supported by the v2ray service")
+	}
 
-			downLink := res.GetStat()
-			if downLink == nil {
-				downLink = &statscommand.Stat{}
-			}
+	return nil
+}
 
-			items = append(
-				items,
-				types.Peer{
-					Key:      key,
-					Upload:   upLink.GetValue(),
-					Download: downLink.GetValue(),
-				},
+func (s *V2Ray) Peers() (items []types.Peer, err error) {
+	var names []string
+	err = s.peers.Iterate(
+		func(key string, _ v2raytypes.Peer) (bool, error) {
+			items = append(items, types.Peer{Key: key})
+			names = append(names,
+				fmt.Sprintf("user>>>%s>>>traffic>>>uplink", key),
+				fmt.Sprintf("user>>>%s>>>traffic>>>downlink", key),
 			)
+
 			return false, nil
 		},
 	)
+	if err != nil {
+		return nil, err
+	}
 
+	values, err := s.backend.stats(names...)
 	if err != nil {
 		return nil, err
 	}
 
+	for i := range items {
+		items[i].Upload = values[2*i]
+		items[i].Download = values[2*i+1]
+	}
+
 	return items, nil
 }
 
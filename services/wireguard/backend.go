@@ -0,0 +1,48 @@
+package wireguard
+
+import (
+	"fmt"
+	"time"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+
+	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
+)
+
+type peerStats struct {
+	Identity        string
+	IPv4            wgtypes.IPv4
+	IPv6            wgtypes.IPv6
+	Upload          int64
+	Download        int64
+	LatestHandshake time.Time
+}
+
+type backend interface {
+	IsUp() bool
+	Up() error
+	Down() error
+	AddPeer(identity string, v4 wgtypes.IPv4, v6 wgtypes.IPv6) error
+	RemovePeer(identity string) error
+	Peers() ([]peerStats, error)
+}
+
+// newBackend returns the backend of the config along with its name. The netlink
+// backend falls back to the exec one where the platform or the kernel does not
+// support it.
+func newBackend(config *wgtypes.Config, log tmlog.Logger) (backend, string, error) {
+	switch config.Backend {
+	case wgtypes.BackendExec:
+		return newExecBackend(config), wgtypes.BackendExec, nil
+	case wgtypes.BackendNetlink:
+		v, err := newNetlinkBackend(config)
+		if err != nil {
+			log.Error("Falling back to the exec backend", "error", err)
+			return newExecBackend(config), wgtypes.BackendExec, nil
+		}
+
+		return v, wgtypes.BackendNetlink, nil
+	default:
+		return nil, "", fmt.Errorf("invalid backend %s", config.Backend)
+	}
+}
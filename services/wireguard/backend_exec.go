@@ -0,0 +1,127 @@
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
+)
+
+var (
+	_ backend = (*execBackend)(nil)
+)
+
+type execBackend struct {
+	iface string
+}
+
+func newExecBackend(config *wgtypes.Config) *execBackend {
+	return &execBackend{
+		iface: config.Interface,
+	}
+}
+
+func (b *execBackend) IsUp() bool {
+	return exec.Command("wg", strings.Split(
+		fmt.Sprintf("show %s", b.iface), " ")...).Run() == nil
+}
+
+func (b *execBackend) Up() error {
+	cmd := exec.Command("wg-quick", strings.Split(
+		fmt.Sprintf("up %s", b.iface), " ")...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (b *execBackend) Down() error {
+	cmd := exec.Command("wg-quick", strings.Split(
+		fmt.Sprintf("down %s", b.iface), " ")...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (b *execBackend) AddPeer(identity string, v4 wgtypes.IPv4, v6 wgtypes.IPv6) error {
+	cmd := exec.Command("wg", strings.Split(
+		fmt.Sprintf(`set %s peer %s allowed-ips %s/32,%s/128`,
+			b.iface, identity, v4.IP(), v6.IP()), " ")...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (b *execBackend) RemovePeer(identity string) error {
+	cmd := exec.Command("wg", strings.Split(
+		fmt.Sprintf(`set %s peer %s remove`,
+			b.iface, identity), " ")...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (b *execBackend) Peers() (items []peerStats, err error) {
+	output, err := exec.Command("wg", strings.Split(
+		fmt.Sprintf("show %s dump", b.iface), " ")...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each peer line is: public-key, preshared-key, endpoint, allowed-ips,
+	// latest-handshake, transfer-rx, transfer-tx, persistent-keepalive
+	lines := strings.Split(string(output), "\n")
+	for _, line := range lines {
+		columns := strings.Split(line, "\t")
+		if len(columns) != 8 {
+			continue
+		}
+
+		item := peerStats{
+			Identity: columns[0],
+		}
+
+		for _, addr := range strings.Split(columns[3], ",") {
+			ip, _, err := net.ParseCIDR(addr)
+			if err != nil {
+				continue
+			}
+
+			if ip.To4() != nil {
+				item.IPv4 = wgtypes.NewIPv4FromIP(ip)
+			} else {
+				item.IPv6 = wgtypes.NewIPv6FromIP(ip)
+			}
+		}
+
+		handshake, err := strconv.ParseInt(columns[4], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if handshake > 0 {
+			item.LatestHandshake = time.Unix(handshake, 0)
+		}
+
+		item.Upload, err = strconv.ParseInt(columns[5], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Download, err = strconv.ParseInt(columns[6], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,217 @@
+package wireguard
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	wgctrltypes "golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
+)
+
+var (
+	_ backend = (*netlinkBackend)(nil)
+)
+
+// netlinkBackend controls the interface through the route netlink family and
+// the WireGuard generic netlink family, without the wg and wg-quick tools.
+type netlinkBackend struct {
+	config *wgtypes.Config
+	client *wgctrl.Client
+}
+
+func newNetlinkBackend(config *wgtypes.Config) (backend, error) {
+	if err := kernelSupport(); err != nil {
+		return nil, err
+	}
+
+	client, err := wgctrl.New()
+	if err != nil {
+		return nil, err
+	}
+
+	return &netlinkBackend{
+		config: config,
+		client: client,
+	}, nil
+}
+
+func (b *netlinkBackend) IsUp() bool {
+	_, err := b.client.Device(b.config.Interface)
+	return err == nil
+}
+
+func (b *netlinkBackend) Up() error {
+	link := &netlink.Wireguard{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: b.config.Interface,
+		},
+	}
+
+	if err := netlink.LinkAdd(link); err != nil && !errors.Is(err, unix.EEXIST) {
+		return err
+	}
+
+	key, err := wgctrltypes.ParseKey(b.config.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	port := int(b.config.ListenPort)
+	err = b.client.ConfigureDevice(
+		b.config.Interface,
+		wgctrltypes.Config{
+			PrivateKey: &key,
+			ListenPort: &port,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range addresses {
+		addr, err := netlink.ParseAddr(s)
+		if err != nil {
+			return err
+		}
+		if err = netlink.AddrReplace(link, addr); err != nil {
+			return err
+		}
+	}
+
+	if err = netlink.LinkSetUp(link); err != nil {
+		return err
+	}
+
+	return b.runHooks(postUp)
+}
+
+func (b *netlinkBackend) Down() error {
+	link, err := netlink.LinkByName(b.config.Interface)
+	if err != nil {
+		return err
+	}
+
+	if err = b.runHooks(postDown); err != nil {
+		return err
+	}
+
+	return netlink.LinkDel(link)
+}
+
+func (b *netlinkBackend) AddPeer(identity string, v4 wgtypes.IPv4, v6 wgtypes.IPv6) error {
+	key, err := wgctrltypes.ParseKey(identity)
+	if err != nil {
+		return err
+	}
+
+	return b.client.ConfigureDevice(
+		b.config.Interface,
+		wgctrltypes.Config{
+			Peers: []wgctrltypes.PeerConfig{
+				{
+					PublicKey:         key,
+					ReplaceAllowedIPs: true,
+					AllowedIPs: []net.IPNet{
+						{IP: v4.IP(), Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)},
+						{IP: v6.IP(), Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)},
+					},
+				},
+			},
+		},
+	)
+}
+
+func (b *netlinkBackend) RemovePeer(identity string) error {
+	key, err := wgctrltypes.ParseKey(identity)
+	if err != nil {
+		return err
+	}
+
+	return b.client.ConfigureDevice(
+		b.config.Interface,
+		wgctrltypes.Config{
+			Peers: []wgctrltypes.PeerConfig{
+				{
+					PublicKey: key,
+					Remove:    true,
+				},
+			},
+		},
+	)
+}
+
+func (b *netlinkBackend) Peers() (items []peerStats, err error) {
+	device, err := b.client.Device(b.config.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, peer := range device.Peers {
+		item := peerStats{
+			Identity:        peer.PublicKey.String(),
+			Upload:          peer.ReceiveBytes,
+			Download:        peer.TransmitBytes,
+			LatestHandshake: peer.LastHandshakeTime,
+		}
+
+		for _, ipNet := range peer.AllowedIPs {
+			if ipNet.IP.To4() != nil {
+				item.IPv4 = wgtypes.NewIPv4FromIP(ipNet.IP)
+			} else {
+				item.IPv6 = wgtypes.NewIPv6FromIP(ipNet.IP)
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (b *netlinkBackend) runHooks(hooks []string) error {
+	for _, hook := range hooks {
+		args := strings.Fields(strings.ReplaceAll(hook, "%i", b.config.Interface))
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// kernelSupport reports whether the kernel serves the WireGuard generic netlink
+// family, or has the module which registers it on creating the interface.
+func kernelSupport() error {
+	if _, err := netlink.GenlFamilyGet("wireguard"); err == nil {
+		return nil
+	}
+
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return err
+	}
+
+	release := unix.ByteSliceToString(uts.Release[:])
+	for _, name := range []string{"modules.builtin", "modules.dep"} {
+		buf, err := os.ReadFile(filepath.Join("/lib/modules", release, name))
+		if err == nil && bytes.Contains(buf, []byte("/wireguard.ko")) {
+			return nil
+		}
+	}
+
+	return errors.New("kernel has no wireguard module")
+}
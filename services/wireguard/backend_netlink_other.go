@@ -0,0 +1,13 @@
+//go:build !linux
+
+package wireguard
+
+import (
+	"errors"
+
+	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
+)
+
+func newNetlinkBackend(_ *wgtypes.Config) (backend, error) {
+	return nil, errors.New("netlink backend is supported only on linux")
+}
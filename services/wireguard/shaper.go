@@ -0,0 +1,201 @@
+package wireguard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
+)
+
+const (
+	// Each class uses two filter priorities, one per address family, so the
+	// class identifiers must stay below half of the 16-bit priority range.
+	maxShaperClass = 0x7fff
+)
+
+type shaperClass struct {
+	id       uint16
+	download bool
+	upload   bool
+}
+
+// shaper caps the rates of the peers with HTB classes for the traffic sent to
+// them and ingress policers for the traffic received from them.
+type shaper struct {
+	iface   string
+	mutex   sync.Mutex
+	ready   bool
+	classes map[string]shaperClass
+}
+
+func newShaper(iface string) *shaper {
+	return &shaper{
+		iface:   iface,
+		classes: make(map[string]shaperClass),
+	}
+}
+
+func (s *shaper) tc(format string, args ...interface{}) error {
+	cmd := exec.Command("tc", strings.Split(fmt.Sprintf(format, args...), " ")...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (s *shaper) setup() error {
+	if s.ready {
+		return nil
+	}
+
+	// The interface may survive a restart of the node along with the
+	// filters installed by the previous process, so start from scratch.
+	_ = exec.Command("tc", "qdisc", "del", "dev", s.iface, "root").Run()
+	_ = exec.Command("tc", "qdisc", "del", "dev", s.iface, "ingress").Run()
+
+	if err := s.tc("qdisc add dev %s root handle 1: htb", s.iface); err != nil {
+		return err
+	}
+	if err := s.tc("qdisc add dev %s ingress", s.iface); err != nil {
+		return err
+	}
+
+	s.ready = true
+	return nil
+}
+
+func (s *shaper) nextID() (uint16, error) {
+	used := make(map[uint16]bool, len(s.classes))
+	for _, class := range s.classes {
+		used[class.id] = true
+	}
+
+	for id := uint16(1); id <= maxShaperClass; id++ {
+		if !used[id] {
+			return id, nil
+		}
+	}
+
+	return 0, errors.New("no shaper classes available")
+}
+
+func (s *shaper) Set(identity string, v4 wgtypes.IPv4, v6 wgtypes.IPv6, download, upload int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.remove(identity); err != nil {
+		return err
+	}
+	if download <= 0 && upload <= 0 {
+		return nil
+	}
+
+	if err := s.setup(); err != nil {
+		return err
+	}
+
+	id, err := s.nextID()
+	if err != nil {
+		return err
+	}
+
+	var (
+		class = shaperClass{id: id}
+		prio4 = 2 * uint32(id)
+		prio6 = prio4 + 1
+	)
+
+	defer func() {
+		if err != nil {
+			s.classes[identity] = class
+			_ = s.remove(identity)
+		}
+	}()
+
+	if download > 0 {
+		class.download = true
+		if err = s.tc("class replace dev %s parent 1: classid 1:%x htb rate %dkbit ceil %dkbit",
+			s.iface, id, download, download); err != nil {
+			return err
+		}
+		if err = s.tc("filter add dev %s parent 1: protocol ip prio %d flower dst_ip %s classid 1:%x",
+			s.iface, prio4, v4.IP(), id); err != nil {
+			return err
+		}
+		if err = s.tc("filter add dev %s parent 1: protocol ipv6 prio %d flower dst_ip %s classid 1:%x",
+			s.iface, prio6, v6.IP(), id); err != nil {
+			return err
+		}
+	}
+
+	if upload > 0 {
+		class.upload = true
+
+		// Allow bursts worth of 100 milliseconds of traffic, but not less
+		// than a few full-sized packets.
+		burst := upload * 1000 / 8 / 10
+		if burst < 16*1024 {
+			burst = 16 * 1024
+		}
+
+		if err = s.tc("filter add dev %s parent ffff: protocol ip prio %d flower src_ip %s action police rate %dkbit burst %d drop",
+			s.iface, prio4, v4.IP(), upload, burst); err != nil {
+			return err
+		}
+		if err = s.tc("filter add dev %s parent ffff: protocol ipv6 prio %d flower src_ip %s action police rate %dkbit burst %d drop",
+			s.iface, prio6, v6.IP(), upload, burst); err != nil {
+			return err
+		}
+	}
+
+	s.classes[identity] = class
+	return nil
+}
+
+func (s *shaper) remove(identity string) (err error) {
+	class, ok := s.classes[identity]
+	if !ok {
+		return nil
+	}
+
+	var (
+		prio4 = 2 * uint32(class.id)
+		prio6 = prio4 + 1
+	)
+
+	// Removing as much as possible even if one of the commands fails, so
+	// that a stale filter does not keep shaping a reused address.
+	if class.download {
+		if e := s.tc("filter del dev %s parent 1: prio %d", s.iface, prio4); e != nil {
+			err = e
+		}
+		if e := s.tc("filter del dev %s parent 1: prio %d", s.iface, prio6); e != nil {
+			err = e
+		}
+		if e := s.tc("class del dev %s classid 1:%x", s.iface, class.id); e != nil {
+			err = e
+		}
+	}
+	if class.upload {
+		if e := s.tc("filter del dev %s parent ffff: prio %d", s.iface, prio4); e != nil {
+			err = e
+		}
+		if e := s.tc("filter del dev %s parent ffff: prio %d", s.iface, prio6); e != nil {
+			err = e
+		}
+	}
+
+	delete(s.classes, identity)
+	return err
+}
+
+func (s *shaper) Remove(identity string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.remove(identity)
+}
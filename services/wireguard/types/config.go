@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"strings"
 	"text/template"
@@ -14,6 +15,10 @@ import (
 
 var (
 	ct = strings.TrimSpace(`
+# Backend to control the interface with, either netlink or exec (wg and wg-quick), which is used
+# in place of netlink where the platform or the kernel does not support it
+backend = "{{ .Backend }}"
+
 # Name of the network interface
 interface = "{{ .Interface }}"
 
@@ -35,6 +40,7 @@ private_key = "{{ .PrivateKey }}"
 )
 
 type Config struct {
+	Backend    string `json:"backend" mapstructure:"backend"`
 	Interface  string `json:"interface" mapstructure:"interface"`
 	ListenPort uint16 `json:"listen_port" mapstructure:"listen_port"`
 	PrivateKey string `json:"private_key" mapstructure:"private_key"`
@@ -45,6 +51,12 @@ func NewConfig() *Config {
 }
 
 func (c *Config) Validate() error {
+	if c.Backend == "" {
+		return errors.New("backend cannot be empty")
+	}
+	if c.Backend != BackendNetlink && c.Backend != BackendExec {
+		return fmt.Errorf("backend must be either %s or %s", BackendNetlink, BackendExec)
+	}
 	if c.Interface == "" {
 		return errors.New("interface cannot be empty")
 	}
@@ -67,6 +79,7 @@ func (c *Config) WithDefaultValues() *Config {
 		panic(err)
 	}
 
+	c.Backend = BackendNetlink
 	c.Interface = "wg0"
 	c.ListenPort = utils.RandomPort()
 	c.PrivateKey = key.String()
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"bytes"
+	"fmt"
 	"net"
 	"sync"
 
@@ -29,7 +31,7 @@ func (p *IPv4Pool) Get() (ip IPv4, err error) {
 	defer p.mutex.Unlock()
 
 	if len(p.available) == 0 {
-		for p.current[3] == 0 || p.current[3] == 255 {
+		for p.current[3] == 0 || p.current[3] == 255 || p.reserved[p.current] {
 			p.current = p.current.Next()
 		}
 		if !p.Net.Contains(p.current.IP()) {
@@ -51,8 +53,48 @@ func (p *IPv4Pool) Release(ip IPv4) {
 
 	if p.reserved[ip] {
 		delete(p.reserved, ip)
-		p.available = append(p.available, ip)
+
+		// Addresses ahead of the cursor are handed out by it later on
+		if bytes.Compare(ip.Bytes(), p.current.Bytes()) < 0 {
+			p.available = append(p.available, ip)
+		}
+	}
+}
+
+func (p *IPv4Pool) Reserve(ip IPv4) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.Net.Contains(ip.IP()) {
+		return fmt.Errorf("ipv4 %s does not belong to the pool", ip.IP())
+	}
+	if p.reserved[ip] {
+		return fmt.Errorf("ipv4 %s is already reserved", ip.IP())
+	}
+
+	if bytes.Compare(ip.Bytes(), p.current.Bytes()) < 0 {
+		i := 0
+		for ; i < len(p.available); i++ {
+			if p.available[i] == ip {
+				break
+			}
+		}
+		if i == len(p.available) {
+			return fmt.Errorf("ipv4 %s is not available", ip.IP())
+		}
+
+		p.available = append(p.available[:i], p.available[i+1:]...)
 	}
+
+	p.reserved[ip] = true
+	return nil
+}
+
+func (p *IPv4Pool) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.reserved)
 }
 
 func NewIPv4PoolFromCIDR(s string) (*IPv4Pool, error) {
@@ -86,6 +128,9 @@ func (p *IPv6Pool) Get() (ip IPv6, err error) {
 	defer p.mutex.Unlock()
 
 	if len(p.available) == 0 {
+		for p.reserved[p.current] {
+			p.current = p.current.Next()
+		}
 		if !p.Net.Contains(p.current.IP()) {
 			return ip, errors.New("ipv6 pool is pull")
 		}
@@ -105,10 +150,50 @@ func (p *IPv6Pool) Release(ip IPv6) {
 
 	if p.reserved[ip] {
 		delete(p.reserved, ip)
-		p.available = append(p.available, ip)
+
+		// Addresses ahead of the cursor are handed out by it later on
+		if bytes.Compare(ip.Bytes(), p.current.Bytes()) < 0 {
+			p.available = append(p.available, ip)
+		}
 	}
 }
 
+func (p *IPv6Pool) Reserve(ip IPv6) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.Net.Contains(ip.IP()) {
+		return fmt.Errorf("ipv6 %s does not belong to the pool", ip.IP())
+	}
+	if p.reserved[ip] {
+		return fmt.Errorf("ipv6 %s is already reserved", ip.IP())
+	}
+
+	if bytes.Compare(ip.Bytes(), p.current.Bytes()) < 0 {
+		i := 0
+		for ; i < len(p.available); i++ {
+			if p.available[i] == ip {
+				break
+			}
+		}
+		if i == len(p.available) {
+			return fmt.Errorf("ipv6 %s is not available", ip.IP())
+		}
+
+		p.available = append(p.available[:i], p.available[i+1:]...)
+	}
+
+	p.reserved[ip] = true
+	return nil
+}
+
+func (p *IPv6Pool) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.reserved)
+}
+
 func NewIPv6PoolFromCIDR(s string) (*IPv6Pool, error) {
 	ip, ipNet, err := net.ParseCIDR(s)
 	if err != nil {
@@ -146,6 +231,18 @@ func (p *IPPool) Get() (IPv4, IPv6, error) {
 	return v4, v6, nil
 }
 
+func (p *IPPool) Reserve(v4 IPv4, v6 IPv6) error {
+	if err := p.V4.Reserve(v4); err != nil {
+		return err
+	}
+	if err := p.V6.Reserve(v6); err != nil {
+		p.V4.Release(v4)
+		return err
+	}
+
+	return nil
+}
+
 func (p *IPPool) Release(v4 IPv4, v6 IPv6) {
 	p.V4.Release(v4)
 	p.V6.Release(v6)
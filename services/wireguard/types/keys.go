@@ -4,3 +4,8 @@ const (
 	Type           = 1
 	ConfigFileName = "wireguard.toml"
 )
+
+const (
+	BackendExec    = "exec"
+	BackendNetlink = "netlink"
+)
@@ -5,15 +5,15 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"text/template"
 
 	"github.com/spf13/viper"
+	tmlog "github.com/tendermint/tendermint/libs/log"
 
+	"github.com/sentinel-official/dvpn-node/metrics"
 	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
 	"github.com/sentinel-official/dvpn-node/types"
 )
@@ -27,21 +27,35 @@ var (
 )
 
 type WireGuard struct {
-	info   []byte
-	config *wgtypes.Config
-	peers  *wgtypes.Peers
-	pool   *wgtypes.IPPool
+	info    []byte
+	backend backend
+	config  *wgtypes.Config
+	log     tmlog.Logger
+	peers   *wgtypes.Peers
+	pool    *wgtypes.IPPool
+	shaper  *shaper
 }
 
-func NewWireGuard(pool *wgtypes.IPPool) types.Service {
+func NewWireGuard(pool *wgtypes.IPPool) *WireGuard {
 	return &WireGuard{
 		pool:   pool,
 		config: wgtypes.NewConfig(),
 		info:   make([]byte, InfoLen),
+		log:    tmlog.NewNopLogger(),
 		peers:  wgtypes.NewPeers(),
 	}
 }
 
+func (s *WireGuard) WithLogger(v tmlog.Logger) *WireGuard {
+	s.log = v
+	return s
+}
+
+func (s *WireGuard) observePool() {
+	metrics.IPPoolReserved.WithLabelValues("ipv4").Set(float64(s.pool.V4.Len()))
+	metrics.IPPoolReserved.WithLabelValues("ipv6").Set(float64(s.pool.V6.Len()))
+}
+
 func (s *WireGuard) Type() uint64 {
 	return wgtypes.Type
 }
@@ -58,21 +72,35 @@ func (s *WireGuard) Init(home string) (err error) {
 		return err
 	}
 
-	t, err := template.New("wireguard_conf").Parse(configTemplate)
+	b, name, err := newBackend(s.config, s.log)
 	if err != nil {
 		return err
 	}
 
-	var buffer bytes.Buffer
-	if err = t.Execute(&buffer, s.config); err != nil {
-		return err
-	}
+	s.log.Info("Using the WireGuard backend", "backend", name)
 
-	path := fmt.Sprintf("/etc/wireguard/%s.conf", s.config.Interface)
-	if err = os.WriteFile(path, buffer.Bytes(), 0600); err != nil {
-		return err
+	// The wg-quick tool of the exec backend reads the interface from the
+	// config file, which is written for the fallback from netlink as well.
+	if name == wgtypes.BackendExec {
+		t, err := template.New("wireguard_conf").Parse(configTemplate)
+		if err != nil {
+			return err
+		}
+
+		var buffer bytes.Buffer
+		if err = t.Execute(&buffer, s.config); err != nil {
+			return err
+		}
+
+		path := fmt.Sprintf("/etc/wireguard/%s.conf", s.config.Interface)
+		if err = os.WriteFile(path, buffer.Bytes(), 0600); err != nil {
+			return err
+		}
 	}
 
+	s.backend = b
+	s.shaper = newShaper(s.config.Interface)
+
 	key, err := wgtypes.KeyFromString(s.config.PrivateKey)
 	if err != nil {
 		return err
@@ -88,22 +116,45 @@ func (s *WireGuard) Info() []byte {
 	return s.info
 }
 
+func (s *WireGuard) loadPeers() error {
+	items, err := s.backend.Peers()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.IPv4.Empty() || item.IPv6.Empty() {
+			continue
+		}
+		if err = s.pool.Reserve(item.IPv4, item.IPv6); err != nil {
+			return err
+		}
+
+		s.peers.Put(
+			wgtypes.Peer{
+				Identity: item.Identity,
+				IPv4:     item.IPv4,
+				IPv6:     item.IPv6,
+			},
+		)
+	}
+
+	s.observePool()
+	return nil
+}
+
 func (s *WireGuard) Start() error {
-	cmd := exec.Command("wg-quick", strings.Split(
-		fmt.Sprintf("up %s", s.config.Interface), " ")...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if !s.backend.IsUp() {
+		if err := s.backend.Up(); err != nil {
+			return err
+		}
+	}
 
-	return cmd.Run()
+	return s.loadPeers()
 }
 
 func (s *WireGuard) Stop() error {
-	cmd := exec.Command("wg-quick", strings.Split(
-		fmt.Sprintf("down %s", s.config.Interface), " ")...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return s.backend.Down()
 }
 
 func (s *WireGuard) AddPeer(data []byte) (result []byte, err error) {
@@ -118,15 +169,10 @@ func (s *WireGuard) AddPeer(data []byte) (result []byte, err error) {
 		if err != nil {
 			s.pool.Release(v4, v6)
 		}
+		s.observePool()
 	}()
 
-	cmd := exec.Command("wg", strings.Split(
-		fmt.Sprintf(`set %s peer %s allowed-ips %s/32,%s/128`,
-			s.config.Interface, identity, v4.IP(), v6.IP()), " ")...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err = cmd.Run(); err != nil {
+	if err = s.backend.AddPeer(identity, v4, v6); err != nil {
 		return nil, err
 	}
 
@@ -143,6 +189,23 @@ func (s *WireGuard) AddPeer(data []byte) (result []byte, err error) {
 	return result, nil
 }
 
+func (s *WireGuard) GetPeer(data []byte) (types.Peer, bool) {
+	var (
+		identity = base64.StdEncoding.EncodeToString(data)
+		peer     = s.peers.Get(identity)
+	)
+
+	if peer.Empty() {
+		return types.Peer{}, false
+	}
+
+	return types.Peer{
+		Key:  peer.Identity,
+		IPv4: peer.IPv4.IP().String(),
+		IPv6: peer.IPv6.IP().String(),
+	}, true
+}
+
 func (s *WireGuard) HasPeer(data []byte) bool {
 	var (
 		identity = base64.StdEncoding.EncodeToString(data)
@@ -155,53 +218,97 @@ func (s *WireGuard) HasPeer(data []byte) bool {
 func (s *WireGuard) RemovePeer(data []byte) error {
 	identity := base64.StdEncoding.EncodeToString(data)
 
-	cmd := exec.Command("wg", strings.Split(
-		fmt.Sprintf(`set %s peer %s remove`,
-			s.config.Interface, identity), " ")...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := s.backend.RemovePeer(identity); err != nil {
 		return err
 	}
 
+	// The peer is gone from the interface, so its addresses are released
+	// even if its shaping rules cannot be removed.
 	if v := s.peers.Get(identity); !v.Empty() {
 		s.peers.Delete(v.Identity)
 		s.pool.Release(v.IPv4, v.IPv6)
+		s.observePool()
 	}
 
-	return nil
+	return s.shaper.Remove(identity)
 }
 
-func (s *WireGuard) Peers() (items []types.Peer, err error) {
-	output, err := exec.Command("wg", strings.Split(
-		fmt.Sprintf("show %s transfer", s.config.Interface), " ")...).Output()
-	if err != nil {
-		return nil, err
-	}
+func (s *WireGuard) RestorePeers(items []types.Peer) error {
+	for _, item := range items {
+		if v := s.peers.Get(item.Key); !v.Empty() {
+			if err := s.shaper.Set(v.Identity, v.IPv4, v.IPv6, item.DownloadRate, item.UploadRate); err != nil {
+				return err
+			}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		columns := strings.Split(line, "\t")
-		if len(columns) != 3 {
 			continue
 		}
 
-		upload, err := strconv.ParseInt(columns[1], 10, 64)
-		if err != nil {
-			return nil, err
+		var (
+			v4 = wgtypes.NewIPv4FromIP(net.ParseIP(item.IPv4))
+			v6 = wgtypes.NewIPv6FromIP(net.ParseIP(item.IPv6))
+		)
+
+		// A peer without usable addresses, such as one persisted before the
+		// addresses were, is skipped so that the other peers are restored and
+		// its client reconnects with new ones.
+		if v4.Empty() || v6.Empty() {
+			s.log.Error("Skipping the peer with invalid addresses",
+				"key", item.Key, "ipv4", item.IPv4, "ipv6", item.IPv6)
+			continue
+		}
+		if err := s.pool.Reserve(v4, v6); err != nil {
+			s.log.Error("Skipping the peer with unavailable addresses",
+				"key", item.Key, "ipv4", item.IPv4, "ipv6", item.IPv6, "error", err)
+			continue
 		}
 
-		download, err := strconv.ParseInt(columns[2], 10, 64)
-		if err != nil {
-			return nil, err
+		if err := s.backend.AddPeer(item.Key, v4, v6); err != nil {
+			s.pool.Release(v4, v6)
+			return err
 		}
 
+		s.peers.Put(
+			wgtypes.Peer{
+				Identity: item.Key,
+				IPv4:     v4,
+				IPv6:     v6,
+			},
+		)
+
+		if err := s.shaper.Set(item.Key, v4, v6, item.DownloadRate, item.UploadRate); err != nil {
+			return err
+		}
+	}
+
+	s.observePool()
+	return nil
+}
+
+func (s *WireGuard) SetPeerRate(data []byte, download, upload int64) error {
+	var (
+		identity = base64.StdEncoding.EncodeToString(data)
+		peer     = s.peers.Get(identity)
+	)
+
+	if peer.Empty() {
+		return fmt.Errorf("peer %s does not exist", identity)
+	}
+
+	return s.shaper.Set(peer.Identity, peer.IPv4, peer.IPv6, download, upload)
+}
+
+func (s *WireGuard) Peers() (items []types.Peer, err error) {
+	peers, err := s.backend.Peers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, peer := range peers {
 		items = append(items,
 			types.Peer{
-				Key:      columns[0],
-				Upload:   upload,
-				Download: download,
+				Key:      peer.Identity,
+				Upload:   peer.Upload,
+				Download: peer.Download,
 			},
 		)
 	}
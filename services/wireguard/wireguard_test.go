@@ -0,0 +1,334 @@
+package wireguard
+
+import (
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+
+	wgtypes "github.com/sentinel-official/dvpn-node/services/wireguard/types"
+	"github.com/sentinel-official/dvpn-node/types"
+)
+
+var (
+	_ backend = (*fakeBackend)(nil)
+)
+
+type fakeBackend struct {
+	up    bool
+	peers map[string]peerStats
+
+	addErr    error
+	removeErr error
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		peers: make(map[string]peerStats),
+	}
+}
+
+func (b *fakeBackend) IsUp() bool { return b.up }
+
+func (b *fakeBackend) Up() error {
+	b.up = true
+	return nil
+}
+
+func (b *fakeBackend) Down() error {
+	b.up = false
+	return nil
+}
+
+func (b *fakeBackend) AddPeer(identity string, v4 wgtypes.IPv4, v6 wgtypes.IPv6) error {
+	if b.addErr != nil {
+		return b.addErr
+	}
+
+	b.peers[identity] = peerStats{Identity: identity, IPv4: v4, IPv6: v6}
+	return nil
+}
+
+func (b *fakeBackend) RemovePeer(identity string) error {
+	if b.removeErr != nil {
+		return b.removeErr
+	}
+
+	delete(b.peers, identity)
+	return nil
+}
+
+func (b *fakeBackend) Peers() (items []peerStats, err error) {
+	for _, item := range b.peers {
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func newTestWireGuard(t *testing.T, b backend) *WireGuard {
+	t.Helper()
+
+	v4, err := wgtypes.NewIPv4PoolFromCIDR(types.IPv4CIDR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v6, err := wgtypes.NewIPv6PoolFromCIDR(types.IPv6CIDR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &WireGuard{
+		info:    make([]byte, InfoLen),
+		backend: b,
+		config:  wgtypes.NewConfig(),
+		log:     tmlog.NewNopLogger(),
+		peers:   wgtypes.NewPeers(),
+		pool:    wgtypes.NewIPPool(v4, v6),
+		shaper:  newShaper("wg0"),
+	}
+}
+
+func newTestPeerKey(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := wgtypes.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key.Public().Bytes()
+}
+
+func TestWireGuard_AddPeer(t *testing.T) {
+	var (
+		b    = newFakeBackend()
+		s    = newTestWireGuard(t, b)
+		data = newTestPeerKey(t)
+	)
+
+	result, err := s.AddPeer(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != net.IPv4len+net.IPv6len {
+		t.Fatalf("expected %d bytes of result, got %d", net.IPv4len+net.IPv6len, len(result))
+	}
+
+	identity := base64.StdEncoding.EncodeToString(data)
+	item, ok := b.peers[identity]
+	if !ok {
+		t.Fatal("expected the peer to be added to the backend")
+	}
+	if !item.IPv4.IP().Equal(result[:net.IPv4len]) || !item.IPv6.IP().Equal(result[net.IPv4len:]) {
+		t.Fatal("expected the result to carry the addresses of the peer")
+	}
+	if !s.HasPeer(data) || s.PeerCount() != 1 {
+		t.Fatal("expected the peer to be tracked")
+	}
+	if s.pool.V4.Len() != 1 || s.pool.V6.Len() != 1 {
+		t.Fatal("expected the addresses to be reserved")
+	}
+}
+
+func TestWireGuard_AddPeerBackendError(t *testing.T) {
+	var (
+		b = newFakeBackend()
+		s = newTestWireGuard(t, b)
+	)
+
+	b.addErr = errors.New("add failed")
+	if _, err := s.AddPeer(newTestPeerKey(t)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if s.PeerCount() != 0 {
+		t.Fatal("expected no peers to be tracked")
+	}
+	if s.pool.V4.Len() != 0 || s.pool.V6.Len() != 0 {
+		t.Fatal("expected the addresses to be released")
+	}
+}
+
+func TestWireGuard_RemovePeer(t *testing.T) {
+	var (
+		b    = newFakeBackend()
+		s    = newTestWireGuard(t, b)
+		data = newTestPeerKey(t)
+	)
+
+	if _, err := s.AddPeer(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemovePeer(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.peers) != 0 {
+		t.Fatal("expected the peer to be removed from the backend")
+	}
+	if s.HasPeer(data) || s.PeerCount() != 0 {
+		t.Fatal("expected the peer to be untracked")
+	}
+	if s.pool.V4.Len() != 0 || s.pool.V6.Len() != 0 {
+		t.Fatal("expected the addresses to be released")
+	}
+}
+
+func TestWireGuard_RestorePeers(t *testing.T) {
+	var (
+		b     = newFakeBackend()
+		s     = newTestWireGuard(t, b)
+		data  = newTestPeerKey(t)
+		items = []types.Peer{
+			{
+				Key:  base64.StdEncoding.EncodeToString(data),
+				IPv4: "10.8.0.10",
+				IPv6: "fd86:ea04:1115::10",
+			},
+		}
+	)
+
+	if err := s.RestorePeers(items); err != nil {
+		t.Fatal(err)
+	}
+
+	peer, ok := s.GetPeer(data)
+	if !ok {
+		t.Fatal("expected the peer to be restored")
+	}
+	if peer.IPv4 != items[0].IPv4 || peer.IPv6 != items[0].IPv6 {
+		t.Fatalf("expected the persisted addresses, got %s and %s", peer.IPv4, peer.IPv6)
+	}
+	if _, ok := b.peers[items[0].Key]; !ok {
+		t.Fatal("expected the peer to be added to the backend")
+	}
+
+	// Restoring again keeps the peer without reserving its addresses twice.
+	if err := s.RestorePeers(items); err != nil {
+		t.Fatal(err)
+	}
+	if s.pool.V4.Len() != 1 || s.pool.V6.Len() != 1 {
+		t.Fatal("expected the addresses to be reserved once")
+	}
+}
+
+func TestWireGuard_RestorePeersSkipsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		item types.Peer
+	}{
+		{
+			name: "legacy row without addresses",
+			item: types.Peer{},
+		},
+		{
+			name: "invalid addresses",
+			item: types.Peer{IPv4: "invalid", IPv6: "fd86:ea04:1115::10"},
+		},
+		{
+			name: "addresses outside of the pool",
+			item: types.Peer{IPv4: "192.168.0.10", IPv6: "fd86:ea04:1115::10"},
+		},
+		{
+			name: "addresses of another peer",
+			item: types.Peer{IPv4: "10.8.0.20", IPv6: "fd86:ea04:1115::20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				b     = newFakeBackend()
+				s     = newTestWireGuard(t, b)
+				valid = types.Peer{
+					Key:  base64.StdEncoding.EncodeToString(newTestPeerKey(t)),
+					IPv4: "10.8.0.20",
+					IPv6: "fd86:ea04:1115::20",
+				}
+				item = tt.item
+			)
+
+			item.Key = base64.StdEncoding.EncodeToString(newTestPeerKey(t))
+			if err := s.RestorePeers([]types.Peer{valid, item}); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := b.peers[item.Key]; ok {
+				t.Fatal("expected the invalid peer to be skipped")
+			}
+			if _, ok := b.peers[valid.Key]; !ok || s.PeerCount() != 1 {
+				t.Fatal("expected the valid peer to be restored")
+			}
+			if s.pool.V4.Len() != 1 || s.pool.V6.Len() != 1 {
+				t.Fatal("expected only the addresses of the valid peer to be reserved")
+			}
+		})
+	}
+}
+
+func TestWireGuard_RestorePeersBackendError(t *testing.T) {
+	var (
+		b    = newFakeBackend()
+		s    = newTestWireGuard(t, b)
+		item = types.Peer{
+			Key:  base64.StdEncoding.EncodeToString(newTestPeerKey(t)),
+			IPv4: "10.8.0.10",
+			IPv6: "fd86:ea04:1115::10",
+		}
+	)
+
+	b.addErr = errors.New("interface is down")
+	if err := s.RestorePeers([]types.Peer{item}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if s.pool.V4.Len() != 0 || s.pool.V6.Len() != 0 {
+		t.Fatal("expected the addresses to be released")
+	}
+}
+
+func TestWireGuard_StartLoadsPeers(t *testing.T) {
+	var (
+		b        = newFakeBackend()
+		s        = newTestWireGuard(t, b)
+		identity = base64.StdEncoding.EncodeToString(newTestPeerKey(t))
+	)
+
+	b.peers[identity] = peerStats{
+		Identity: identity,
+		IPv4:     wgtypes.NewIPv4FromIP(net.ParseIP("10.8.0.20")),
+		IPv6:     wgtypes.NewIPv6FromIP(net.ParseIP("fd86:ea04:1115::20")),
+	}
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !b.up {
+		t.Fatal("expected the backend to be up")
+	}
+	if s.PeerCount() != 1 {
+		t.Fatal("expected the existing peer to be loaded")
+	}
+	if s.pool.V4.Len() != 1 || s.pool.V6.Len() != 1 {
+		t.Fatal("expected the addresses of the existing peer to be reserved")
+	}
+}
+
+func TestWireGuard_Peers(t *testing.T) {
+	var (
+		b        = newFakeBackend()
+		s        = newTestWireGuard(t, b)
+		identity = base64.StdEncoding.EncodeToString(newTestPeerKey(t))
+	)
+
+	b.peers[identity] = peerStats{Identity: identity, Upload: 10, Download: 20}
+
+	items, err := s.Peers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Key != identity || items[0].Upload != 10 || items[0].Download != 20 {
+		t.Fatalf("unexpected peers %v", items)
+	}
+}
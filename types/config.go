@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -15,10 +16,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 
+	"github.com/sentinel-official/dvpn-node/libs/bandwidth"
+	"github.com/sentinel-official/dvpn-node/libs/pricing"
 	"github.com/sentinel-official/dvpn-node/utils"
 )
 
 const (
+	MinAdminTokenLength       = 16
 	MinPeers                  = 1
 	MaxPeers                  = 250
 	MinMonikerLength          = 4
@@ -29,11 +33,65 @@ const (
 	MaxIntervalUpdateSessions = (2 * time.Hour) - (5 * time.Minute)
 	MinIntervalUpdateStatus   = (30 * time.Minute) - (5 * time.Minute)
 	MaxIntervalUpdateStatus   = (1 * time.Hour) - (5 * time.Minute)
+	MinIntervalSpeedTest      = 1 * time.Hour
+	MinIntervalBalance        = 1 * time.Minute
+	MinIntervalPricing        = 1 * time.Minute
 )
 
 var (
 	ct = strings.TrimSpace(`
+[admin]
+# Enable the operator admin API
+enable = {{ .Admin.Enable }}
+
+# Admin API listen-address, served separately from the API
+listen_on = "{{ .Admin.ListenOn }}"
+
+# Bearer token expected in the Authorization header
+token = "{{ .Admin.Token }}"
+
+# CA certificate file to verify the client certificates against (mutual TLS)
+client_ca_file = "{{ .Admin.ClientCAFile }}"
+
+[balance]
+# Watch the balance of the account paying the transaction fees
+enable = {{ .Balance.Enable }}
+
+# Time interval between the balance checks
+interval = "{{ .Balance.Interval }}"
+
+# Minimum balance to keep regardless of the fee estimate (e.g., 10000000udvpn)
+min_balance = "{{ .Balance.MinBalance }}"
+
+# Time the balance must cover at the rate of the recent transaction fees
+runway = "{{ .Balance.Runway }}"
+
+# URL to POST a JSON alert to when the balance is low
+webhook = "{{ .Balance.Webhook }}"
+
+[cache]
+# Cache the results of the chain queries
+enable = {{ .Cache.Enable }}
+
+# Time to keep the accounts having a public key
+account_ttl = "{{ .Cache.AccountTTL }}"
+
+# Time to keep the membership of the node in a plan
+plan_ttl = "{{ .Cache.PlanTTL }}"
+
+# Time to keep the active nodes and the plans not having the node
+status_ttl = "{{ .Cache.StatusTTL }}"
+
+# Maximum number of cached query results
+max_entries = {{ .Cache.MaxEntries }}
+
 [chain]
+# Mode to broadcast the transactions with (sync, async or block)
+broadcast_mode = "{{ .Chain.BroadcastMode }}"
+
+# Account which granted a fee allowance to pay the transaction fees (empty means the signer pays)
+fee_granter = "{{ .Chain.FeeGranter }}"
+
 # Gas limit to set per transaction
 gas = {{ .Chain.Gas }}
 
@@ -46,7 +104,10 @@ gas_prices = "{{ .Chain.GasPrices }}"
 # The network chain ID
 id = "{{ .Chain.ID }}"
 
-# Comma separated Tendermint RPC addresses for the chain
+# Maximum gas of a transaction carrying the session proofs, larger batches are split
+max_tx_gas = {{ .Chain.MaxTxGas }}
+
+# Comma separated addresses for the chain; Tendermint RPC (http, https), gRPC (grpc, grpcs) or LCD (lcd+http, lcd+https)
 rpc_addresses = "{{ .Chain.RPCAddresses }}"
 
 # Timeout seconds for querying the data from the RPC server
@@ -58,6 +119,28 @@ rpc_tx_timeout = {{ .Chain.RPCTxTimeout }}
 # Calculate the transaction fee by simulating it
 simulate_and_execute = {{ .Chain.SimulateAndExecute }}
 
+# Timeout seconds for the transactions broadcast in sync or async mode to be included in a block (0 means do not wait)
+tx_confirm_timeout = {{ .Chain.TxConfirmTimeout }}
+
+[geoip]
+# Providers to find the location with, tried in order (ip-api, ipinfo, maxmind, static)
+providers = [{{ range $i, $v := .GeoIP.Providers }}{{ if $i }}, {{ end }}"{{ $v }}"{{ end }}]
+
+# Timeout of the requests to the online providers
+timeout = "{{ .GeoIP.Timeout }}"
+
+# Access token for ipinfo.io (optional)
+ipinfo_token = "{{ .GeoIP.IPInfoToken }}"
+
+# Path to a MaxMind GeoLite2 or GeoIP2 City database, looked up with node.ipv4_address
+maxmind_database = "{{ .GeoIP.MaxMindDatabase }}"
+
+# Static location, reported with node.ipv4_address
+static_city = "{{ .GeoIP.StaticCity }}"
+static_country = "{{ .GeoIP.StaticCountry }}"
+static_latitude = {{ .GeoIP.StaticLatitude }}
+static_longitude = {{ .GeoIP.StaticLongitude }}
+
 [handshake]
 # Enable Handshake DNS resolver
 enable = {{ .Handshake.Enable }}
@@ -72,6 +155,16 @@ backend = "{{ .Keyring.Backend }}"
 # Name of the key with which to sign
 from = "{{ .Keyring.From }}"
 
+# Operator account which granted the from key to execute the node messages through authz (empty means the from key is the operator)
+granter = "{{ .Keyring.Granter }}"
+
+[metrics]
+# Enable the Prometheus metrics endpoint
+enable = {{ .Metrics.Enable }}
+
+# Metrics listen-address, served separately from the API
+listen_on = "{{ .Metrics.ListenOn }}"
+
 [node]
 # Time interval between each set_sessions operation
 interval_set_sessions = "{{ .Node.IntervalSetSessions }}"
@@ -103,9 +196,86 @@ remote_url = "{{ .Node.RemoteURL }}"
 # Type of node
 type = "{{ .Node.Type }}"
 
+[pricing]
+# Adjust the published prices by the rules below, starting from the prices of the node
+enable = {{ .Pricing.Enable }}
+
+# Time interval between the price evaluations
+interval = "{{ .Pricing.Interval }}"
+
+# JSON file with the gigabyte_prices and hourly_prices replacing the prices of the node
+feed_file = "{{ .Pricing.FeedFile }}"
+
+# Price multiplier at max_peers, scaled linearly with the peer count (1 to disable)
+utilisation_multiplier = {{ .Pricing.UtilisationMultiplier }}
+
+# Daily UTC hours of the peak prices (e.g., 18-23)
+peak_hours = "{{ .Pricing.PeakHours }}"
+
+# Price multiplier within the peak hours
+peak_multiplier = {{ .Pricing.PeakMultiplier }}
+
+# Minimum relative change of a price to publish the new prices
+threshold = {{ .Pricing.Threshold }}
+
+# Maximum fees to spend on publishing the prices within the budget period (e.g., 1000000udvpn)
+fee_budget = "{{ .Pricing.FeeBudget }}"
+
+# Time period of the fee budget
+budget_period = "{{ .Pricing.BudgetPeriod }}"
+
 [qos]
 # Limit max number of concurrent peers
 max_peers = {{ .QOS.MaxPeers }}
+
+# Maximum download and upload rates of a peer in kilobits per second (0 means unlimited)
+download_rate = {{ .QOS.DownloadRate }}
+upload_rate = {{ .QOS.UploadRate }}
+
+# Rates for the peers of hourly node subscriptions (0 means same as above)
+hourly_download_rate = {{ .QOS.HourlyDownloadRate }}
+hourly_upload_rate = {{ .QOS.HourlyUploadRate }}
+
+# Rates for the peers of plan subscriptions (0 means same as above)
+plan_download_rate = {{ .QOS.PlanDownloadRate }}
+plan_upload_rate = {{ .QOS.PlanUploadRate }}
+
+[speedtest]
+# Enable the periodic speed test
+enable = {{ .SpeedTest.Enable }}
+
+# Time interval between each speed test
+interval = "{{ .SpeedTest.Interval }}"
+
+# Probers to measure the bandwidth with, tried in order (speedtest, http, iperf3, static)
+probers = [{{ range $i, $v := .SpeedTest.Probers }}{{ if $i }}, {{ end }}"{{ $v }}"{{ end }}]
+
+# Identifiers of the speedtest.net servers to use (empty means the closest ones)
+servers = [{{ range $i, $v := .SpeedTest.Servers }}{{ if $i }}, {{ end }}{{ $v }}{{ end }}]
+
+# Duration of each direction of the http and iperf3 tests
+duration = "{{ .SpeedTest.Duration }}"
+
+# URLs of a self-hosted endpoint to download from and upload to
+http_download_url = "{{ .SpeedTest.HTTPDownloadURL }}"
+http_upload_url = "{{ .SpeedTest.HTTPUploadURL }}"
+
+# Address of an iperf3 server
+iperf3_address = "{{ .SpeedTest.Iperf3Address }}"
+
+# Static download and upload speeds in megabits per second
+static_download = {{ .SpeedTest.StaticDownload }}
+static_upload = {{ .SpeedTest.StaticUpload }}
+
+# Minimum download and upload speeds in megabits per second (0 means no minimum)
+min_download = {{ .SpeedTest.MinDownload }}
+min_upload = {{ .SpeedTest.MinUpload }}
+
+# Refuse to start when the speed is below the minimum, instead of a warning
+refuse_below_min = {{ .SpeedTest.RefuseBelowMin }}
+
+# Skip the speed test while the peers use more than this fraction of the bandwidth (0 means never skip)
+skip_threshold = {{ .SpeedTest.SkipThreshold }}
 	`)
 
 	t = func() *template.Template {
@@ -118,15 +288,154 @@ max_peers = {{ .QOS.MaxPeers }}
 	}()
 )
 
+type AdminConfig struct {
+	ClientCAFile string `json:"client_ca_file" mapstructure:"client_ca_file"`
+	Enable       bool   `json:"enable" mapstructure:"enable"`
+	ListenOn     string `json:"listen_on" mapstructure:"listen_on"`
+	Token        string `json:"-" mapstructure:"token"`
+}
+
+func NewAdminConfig() *AdminConfig {
+	return &AdminConfig{}
+}
+
+func (c *AdminConfig) Validate() error {
+	if c.Enable {
+		if c.ListenOn == "" {
+			return errors.New("listen_on cannot be empty")
+		}
+		if _, _, err := net.SplitHostPort(c.ListenOn); err != nil {
+			return errors.Wrap(err, "invalid listen_on")
+		}
+		if c.Token == "" && c.ClientCAFile == "" {
+			return errors.New("token and client_ca_file cannot be empty at the same time")
+		}
+	}
+	if c.Token != "" && len(c.Token) < MinAdminTokenLength {
+		return fmt.Errorf("token length cannot be less than %d", MinAdminTokenLength)
+	}
+
+	return nil
+}
+
+func (c *AdminConfig) WithDefaultValues() *AdminConfig {
+	c.ClientCAFile = ""
+	c.Enable = false
+	c.ListenOn = "127.0.0.1:8586"
+	c.Token = ""
+
+	return c
+}
+
+type BalanceConfig struct {
+	Enable     bool          `json:"enable" mapstructure:"enable"`
+	Interval   time.Duration `json:"interval" mapstructure:"interval"`
+	MinBalance string        `json:"min_balance" mapstructure:"min_balance"`
+	Runway     time.Duration `json:"runway" mapstructure:"runway"`
+	Webhook    string        `json:"webhook" mapstructure:"webhook"`
+}
+
+func NewBalanceConfig() *BalanceConfig {
+	return &BalanceConfig{}
+}
+
+func (c *BalanceConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval < MinIntervalBalance {
+		return fmt.Errorf("interval cannot be less than %s", MinIntervalBalance)
+	}
+	if _, err := sdk.ParseCoinsNormalized(c.MinBalance); err != nil {
+		return errors.Wrap(err, "invalid min_balance")
+	}
+	if c.Runway < 0 {
+		return errors.New("runway cannot be negative")
+	}
+	if c.Webhook != "" {
+		if _, err := url.ParseRequestURI(c.Webhook); err != nil {
+			return errors.Wrap(err, "invalid webhook")
+		}
+	}
+
+	return nil
+}
+
+func (c *BalanceConfig) WithDefaultValues() *BalanceConfig {
+	c.Enable = true
+	c.Interval = 1 * time.Hour
+	c.MinBalance = ""
+	c.Runway = 72 * time.Hour
+	c.Webhook = ""
+
+	return c
+}
+
+// MinBalanceCoins returns the configured minimum balance.
+func (c *BalanceConfig) MinBalanceCoins() sdk.Coins {
+	v, err := sdk.ParseCoinsNormalized(c.MinBalance)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+type CacheConfig struct {
+	AccountTTL time.Duration `json:"account_ttl" mapstructure:"account_ttl"`
+	Enable     bool          `json:"enable" mapstructure:"enable"`
+	MaxEntries int           `json:"max_entries" mapstructure:"max_entries"`
+	PlanTTL    time.Duration `json:"plan_ttl" mapstructure:"plan_ttl"`
+	StatusTTL  time.Duration `json:"status_ttl" mapstructure:"status_ttl"`
+}
+
+func NewCacheConfig() *CacheConfig {
+	return &CacheConfig{}
+}
+
+func (c *CacheConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.AccountTTL < 0 {
+		return errors.New("account_ttl cannot be negative")
+	}
+	if c.MaxEntries <= 0 {
+		return errors.New("max_entries must be positive")
+	}
+	if c.PlanTTL < 0 {
+		return errors.New("plan_ttl cannot be negative")
+	}
+	if c.StatusTTL < 0 {
+		return errors.New("status_ttl cannot be negative")
+	}
+
+	return nil
+}
+
+func (c *CacheConfig) WithDefaultValues() *CacheConfig {
+	c.AccountTTL = 1 * time.Hour
+	c.Enable = false
+	c.MaxEntries = 10_000
+	c.PlanTTL = 1 * time.Hour
+	c.StatusTTL = 30 * time.Second
+
+	return c
+}
+
 type ChainConfig struct {
+	BroadcastMode      string  `json:"broadcast_mode" mapstructure:"broadcast_mode"`
+	FeeGranter         string  `json:"fee_granter" mapstructure:"fee_granter"`
 	Gas                uint64  `json:"gas" mapstructure:"gas"`
 	GasAdjustment      float64 `json:"gas_adjustment" mapstructure:"gas_adjustment"`
 	GasPrices          string  `json:"gas_prices" mapstructure:"gas_prices"`
 	ID                 string  `json:"id" mapstructure:"id"`
+	MaxTxGas           uint64  `json:"max_tx_gas" mapstructure:"max_tx_gas"`
 	RPCAddresses       string  `json:"rpc_addresses" mapstructure:"rpc_addresses"`
 	RPCQueryTimeout    uint    `json:"rpc_query_timeout" mapstructure:"rpc_query_timeout"`
 	RPCTxTimeout       uint    `json:"rpc_tx_timeout" mapstructure:"rpc_tx_timeout"`
 	SimulateAndExecute bool    `json:"simulate_and_execute" mapstructure:"simulate_and_execute"`
+	TxConfirmTimeout   uint    `json:"tx_confirm_timeout" mapstructure:"tx_confirm_timeout"`
 }
 
 func NewChainConfig() *ChainConfig {
@@ -134,6 +443,14 @@ func NewChainConfig() *ChainConfig {
 }
 
 func (c *ChainConfig) Validate() error {
+	if c.BroadcastMode != "sync" && c.BroadcastMode != "async" && c.BroadcastMode != "block" {
+		return errors.New("broadcast_mode must be one of sync, async or block")
+	}
+	if c.FeeGranter != "" {
+		if _, err := sdk.AccAddressFromBech32(c.FeeGranter); err != nil {
+			return errors.Wrap(err, "invalid fee_granter")
+		}
+	}
 	if c.Gas <= 0 {
 		return errors.New("gas must be positive")
 	}
@@ -146,6 +463,9 @@ func (c *ChainConfig) Validate() error {
 	if c.ID == "" {
 		return errors.New("id cannot be empty")
 	}
+	if c.MaxTxGas < c.Gas {
+		return errors.New("max_tx_gas cannot be less than gas")
+	}
 	if c.RPCAddresses == "" {
 		return errors.New("rpc_addresses cannot be empty")
 	}
@@ -156,8 +476,10 @@ func (c *ChainConfig) Validate() error {
 		if err != nil {
 			return errors.Wrapf(err, "invalid rpc_address %s", items[i])
 		}
-		if uri.Scheme != "http" && uri.Scheme != "https" {
-			return errors.New("rpc_address scheme must be either http or https")
+		switch uri.Scheme {
+		case "http", "https", "grpc", "grpcs", "lcd+http", "lcd+https":
+		default:
+			return errors.New("rpc_address scheme must be one of http, https, grpc, grpcs, lcd+http or lcd+https")
 		}
 		if uri.Port() == "" {
 			return errors.New("rpc_address port cannot be empty")
@@ -175,14 +497,77 @@ func (c *ChainConfig) Validate() error {
 }
 
 func (c *ChainConfig) WithDefaultValues() *ChainConfig {
+	c.BroadcastMode = "sync"
+	c.FeeGranter = ""
 	c.Gas = 200_000
 	c.GasAdjustment = 1.05
 	c.GasPrices = "0.1udvpn"
 	c.ID = "sentinelhub-2"
+	c.MaxTxGas = 2_000_000
 	c.RPCAddresses = "https://rpc.sentinel.co:443"
 	c.RPCQueryTimeout = 10
 	c.RPCTxTimeout = 30
 	c.SimulateAndExecute = true
+	c.TxConfirmTimeout = 60
+
+	return c
+}
+
+type GeoIPConfig struct {
+	IPInfoToken     string        `json:"-" mapstructure:"ipinfo_token"`
+	MaxMindDatabase string        `json:"maxmind_database" mapstructure:"maxmind_database"`
+	Providers       []string      `json:"providers" mapstructure:"providers"`
+	StaticCity      string        `json:"static_city" mapstructure:"static_city"`
+	StaticCountry   string        `json:"static_country" mapstructure:"static_country"`
+	StaticLatitude  float64       `json:"static_latitude" mapstructure:"static_latitude"`
+	StaticLongitude float64       `json:"static_longitude" mapstructure:"static_longitude"`
+	Timeout         time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+func NewGeoIPConfig() *GeoIPConfig {
+	return &GeoIPConfig{}
+}
+
+func (c *GeoIPConfig) Validate() error {
+	if len(c.Providers) == 0 {
+		return errors.New("providers cannot be empty")
+	}
+
+	for _, provider := range c.Providers {
+		switch provider {
+		case GeoIPProviderIPAPI, GeoIPProviderIPInfo:
+		case GeoIPProviderMaxMind:
+			if c.MaxMindDatabase == "" {
+				return errors.New("maxmind_database cannot be empty")
+			}
+		case GeoIPProviderStatic:
+			if c.StaticLatitude < -90 || c.StaticLatitude > 90 {
+				return errors.New("static_latitude must be in the range [-90, 90]")
+			}
+			if c.StaticLongitude < -180 || c.StaticLongitude > 180 {
+				return errors.New("static_longitude must be in the range [-180, 180]")
+			}
+		default:
+			return fmt.Errorf("unknown provider %s", provider)
+		}
+	}
+
+	if c.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+
+	return nil
+}
+
+func (c *GeoIPConfig) WithDefaultValues() *GeoIPConfig {
+	c.IPInfoToken = ""
+	c.MaxMindDatabase = ""
+	c.Providers = []string{GeoIPProviderIPAPI, GeoIPProviderIPInfo}
+	c.StaticCity = ""
+	c.StaticCountry = ""
+	c.StaticLatitude = 0
+	c.StaticLongitude = 0
+	c.Timeout = 15 * time.Second
 
 	return c
 }
@@ -216,6 +601,7 @@ func (c *HandshakeConfig) WithDefaultValues() *HandshakeConfig {
 type KeyringConfig struct {
 	Backend string `json:"backend" mapstructure:"backend"`
 	From    string `json:"from" mapstructure:"from"`
+	Granter string `json:"granter" mapstructure:"granter"`
 }
 
 func NewKeyringConfig() *KeyringConfig {
@@ -232,6 +618,11 @@ func (c *KeyringConfig) Validate() error {
 	if c.From == "" {
 		return errors.New("from cannot be empty")
 	}
+	if c.Granter != "" {
+		if _, err := sdk.AccAddressFromBech32(c.Granter); err != nil {
+			return errors.Wrap(err, "invalid granter")
+		}
+	}
 
 	return nil
 }
@@ -239,6 +630,36 @@ func (c *KeyringConfig) Validate() error {
 func (c *KeyringConfig) WithDefaultValues() *KeyringConfig {
 	c.Backend = keyring.BackendFile
 	c.From = "operator"
+	c.Granter = ""
+
+	return c
+}
+
+type MetricsConfig struct {
+	Enable   bool   `json:"enable" mapstructure:"enable"`
+	ListenOn string `json:"listen_on" mapstructure:"listen_on"`
+}
+
+func NewMetricsConfig() *MetricsConfig {
+	return &MetricsConfig{}
+}
+
+func (c *MetricsConfig) Validate() error {
+	if c.Enable {
+		if c.ListenOn == "" {
+			return errors.New("listen_on cannot be empty")
+		}
+		if _, _, err := net.SplitHostPort(c.ListenOn); err != nil {
+			return errors.Wrap(err, "invalid listen_on")
+		}
+	}
+
+	return nil
+}
+
+func (c *MetricsConfig) WithDefaultValues() *MetricsConfig {
+	c.Enable = false
+	c.ListenOn = "127.0.0.1:9100"
 
 	return c
 }
@@ -347,8 +768,119 @@ func (c *NodeConfig) WithDefaultValues() *NodeConfig {
 	return c
 }
 
+type PricingConfig struct {
+	BudgetPeriod          time.Duration `json:"budget_period" mapstructure:"budget_period"`
+	Enable                bool          `json:"enable" mapstructure:"enable"`
+	FeeBudget             string        `json:"fee_budget" mapstructure:"fee_budget"`
+	FeedFile              string        `json:"feed_file" mapstructure:"feed_file"`
+	Interval              time.Duration `json:"interval" mapstructure:"interval"`
+	PeakHours             string        `json:"peak_hours" mapstructure:"peak_hours"`
+	PeakMultiplier        float64       `json:"peak_multiplier" mapstructure:"peak_multiplier"`
+	Threshold             float64       `json:"threshold" mapstructure:"threshold"`
+	UtilisationMultiplier float64       `json:"utilisation_multiplier" mapstructure:"utilisation_multiplier"`
+}
+
+func NewPricingConfig() *PricingConfig {
+	return &PricingConfig{}
+}
+
+func (c *PricingConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval < MinIntervalPricing {
+		return fmt.Errorf("interval cannot be less than %s", MinIntervalPricing)
+	}
+	if c.PeakHours != "" {
+		if _, _, err := c.ParsePeakHours(); err != nil {
+			return errors.Wrap(err, "invalid peak_hours")
+		}
+	}
+	if c.PeakMultiplier <= 0 {
+		return errors.New("peak_multiplier must be positive")
+	}
+	if c.Threshold < 0 {
+		return errors.New("threshold cannot be negative")
+	}
+	if c.UtilisationMultiplier <= 0 {
+		return errors.New("utilisation_multiplier must be positive")
+	}
+	if _, err := sdk.ParseCoinsNormalized(c.FeeBudget); err != nil {
+		return errors.Wrap(err, "invalid fee_budget")
+	}
+	if c.FeeBudget != "" && c.BudgetPeriod <= 0 {
+		return errors.New("budget_period must be positive")
+	}
+
+	return nil
+}
+
+func (c *PricingConfig) WithDefaultValues() *PricingConfig {
+	c.BudgetPeriod = 24 * time.Hour
+	c.Enable = false
+	c.FeeBudget = ""
+	c.FeedFile = ""
+	c.Interval = 5 * time.Minute
+	c.PeakHours = ""
+	c.PeakMultiplier = 1
+	c.Threshold = 0.05
+	c.UtilisationMultiplier = 1
+
+	return c
+}
+
+// ParsePeakHours returns the start and the end hours of the peak_hours range.
+func (c *PricingConfig) ParsePeakHours() (start, end int, err error) {
+	if _, err = fmt.Sscanf(c.PeakHours, "%d-%d", &start, &end); err != nil {
+		return 0, 0, err
+	}
+	if start < 0 || start > 23 || end < 0 || end > 24 || start == end {
+		return 0, 0, fmt.Errorf("invalid range %d-%d", start, end)
+	}
+
+	return start, end, nil
+}
+
+func (c *PricingConfig) FeeBudgetCoins() sdk.Coins {
+	v, err := sdk.ParseCoinsNormalized(c.FeeBudget)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func (c *PricingConfig) Rules() (items []pricing.Rule) {
+	if c.FeedFile != "" {
+		items = append(items, pricing.NewFeedRule(c.FeedFile))
+	}
+	if c.UtilisationMultiplier != 1 {
+		items = append(items, pricing.NewUtilisationRule(floatToDec(c.UtilisationMultiplier)))
+	}
+	if c.PeakHours != "" && c.PeakMultiplier != 1 {
+		start, end, err := c.ParsePeakHours()
+		if err != nil {
+			panic(err)
+		}
+
+		items = append(items, pricing.NewPeakRule(start, end, floatToDec(c.PeakMultiplier)))
+	}
+
+	return items
+}
+
+func floatToDec(v float64) sdk.Dec {
+	return sdk.MustNewDecFromStr(strconv.FormatFloat(v, 'f', sdk.Precision, 64))
+}
+
 type QOSConfig struct {
-	MaxPeers int `json:"max_peers" mapstructure:"max_peers"`
+	MaxPeers           int   `json:"max_peers" mapstructure:"max_peers"`
+	DownloadRate       int64 `json:"download_rate" mapstructure:"download_rate"`
+	UploadRate         int64 `json:"upload_rate" mapstructure:"upload_rate"`
+	HourlyDownloadRate int64 `json:"hourly_download_rate" mapstructure:"hourly_download_rate"`
+	HourlyUploadRate   int64 `json:"hourly_upload_rate" mapstructure:"hourly_upload_rate"`
+	PlanDownloadRate   int64 `json:"plan_download_rate" mapstructure:"plan_download_rate"`
+	PlanUploadRate     int64 `json:"plan_upload_rate" mapstructure:"plan_upload_rate"`
 }
 
 func NewQOSConfig() *QOSConfig {
@@ -363,65 +895,298 @@ func (c *QOSConfig) Validate() error {
 		return fmt.Errorf("max_peers cannot be greater than %d", MaxPeers)
 	}
 
+	if c.DownloadRate < 0 {
+		return errors.New("download_rate cannot be negative")
+	}
+	if c.UploadRate < 0 {
+		return errors.New("upload_rate cannot be negative")
+	}
+	if c.HourlyDownloadRate < 0 {
+		return errors.New("hourly_download_rate cannot be negative")
+	}
+	if c.HourlyUploadRate < 0 {
+		return errors.New("hourly_upload_rate cannot be negative")
+	}
+	if c.PlanDownloadRate < 0 {
+		return errors.New("plan_download_rate cannot be negative")
+	}
+	if c.PlanUploadRate < 0 {
+		return errors.New("plan_upload_rate cannot be negative")
+	}
+
 	return nil
 }
 
 func (c *QOSConfig) WithDefaultValues() *QOSConfig {
 	c.MaxPeers = MaxPeers
+	c.DownloadRate = 0
+	c.UploadRate = 0
+	c.HourlyDownloadRate = 0
+	c.HourlyUploadRate = 0
+	c.PlanDownloadRate = 0
+	c.PlanUploadRate = 0
+
+	return c
+}
+
+func (c *QOSConfig) IsRateLimited() bool {
+	return c.DownloadRate > 0 || c.UploadRate > 0 ||
+		c.HourlyDownloadRate > 0 || c.HourlyUploadRate > 0 ||
+		c.PlanDownloadRate > 0 || c.PlanUploadRate > 0
+}
+
+// Rates returns the download and upload rates of a peer depending on the
+// kind of subscription its session belongs to.
+func (c *QOSConfig) Rates(plan, hourly bool) (download, upload int64) {
+	download, upload = c.DownloadRate, c.UploadRate
+	if plan {
+		if c.PlanDownloadRate > 0 {
+			download = c.PlanDownloadRate
+		}
+		if c.PlanUploadRate > 0 {
+			upload = c.PlanUploadRate
+		}
+	} else if hourly {
+		if c.HourlyDownloadRate > 0 {
+			download = c.HourlyDownloadRate
+		}
+		if c.HourlyUploadRate > 0 {
+			upload = c.HourlyUploadRate
+		}
+	}
+
+	return download, upload
+}
+
+type SpeedTestConfig struct {
+	Duration        time.Duration `json:"duration" mapstructure:"duration"`
+	Enable          bool          `json:"enable" mapstructure:"enable"`
+	HTTPDownloadURL string        `json:"http_download_url" mapstructure:"http_download_url"`
+	HTTPUploadURL   string        `json:"http_upload_url" mapstructure:"http_upload_url"`
+	Interval        time.Duration `json:"interval" mapstructure:"interval"`
+	Iperf3Address   string        `json:"iperf3_address" mapstructure:"iperf3_address"`
+	MinDownload     float64       `json:"min_download" mapstructure:"min_download"`
+	MinUpload       float64       `json:"min_upload" mapstructure:"min_upload"`
+	Probers         []string      `json:"probers" mapstructure:"probers"`
+	RefuseBelowMin  bool          `json:"refuse_below_min" mapstructure:"refuse_below_min"`
+	Servers         []int         `json:"servers" mapstructure:"servers"`
+	SkipThreshold   float64       `json:"skip_threshold" mapstructure:"skip_threshold"`
+	StaticDownload  float64       `json:"static_download" mapstructure:"static_download"`
+	StaticUpload    float64       `json:"static_upload" mapstructure:"static_upload"`
+}
+
+func NewSpeedTestConfig() *SpeedTestConfig {
+	return &SpeedTestConfig{}
+}
+
+func (c *SpeedTestConfig) Validate() error {
+	if c.Enable {
+		if c.Interval < MinIntervalSpeedTest {
+			return fmt.Errorf("interval cannot be less than %s", MinIntervalSpeedTest)
+		}
+	}
+	if len(c.Probers) == 0 {
+		return errors.New("probers cannot be empty")
+	}
+
+	for _, prober := range c.Probers {
+		switch prober {
+		case SpeedTestProberSpeedTest:
+		case SpeedTestProberHTTP:
+			if _, err := url.ParseRequestURI(c.HTTPDownloadURL); err != nil {
+				return errors.Wrap(err, "invalid http_download_url")
+			}
+			if _, err := url.ParseRequestURI(c.HTTPUploadURL); err != nil {
+				return errors.Wrap(err, "invalid http_upload_url")
+			}
+		case SpeedTestProberIperf3:
+			if _, _, err := net.SplitHostPort(c.Iperf3Address); err != nil {
+				return errors.Wrap(err, "invalid iperf3_address")
+			}
+		case SpeedTestProberStatic:
+			if c.StaticDownload <= 0 {
+				return errors.New("static_download must be positive")
+			}
+			if c.StaticUpload <= 0 {
+				return errors.New("static_upload must be positive")
+			}
+		default:
+			return fmt.Errorf("unknown prober %s", prober)
+		}
+	}
+
+	if c.Duration < time.Second {
+		return errors.New("duration cannot be less than 1s")
+	}
+	if c.MinDownload < 0 {
+		return errors.New("min_download cannot be negative")
+	}
+	if c.MinUpload < 0 {
+		return errors.New("min_upload cannot be negative")
+	}
+	if c.SkipThreshold < 0 || c.SkipThreshold > 1 {
+		return errors.New("skip_threshold must be in the range [0, 1]")
+	}
+
+	return nil
+}
+
+func (c *SpeedTestConfig) WithDefaultValues() *SpeedTestConfig {
+	c.Duration = 10 * time.Second
+	c.Enable = false
+	c.HTTPDownloadURL = ""
+	c.HTTPUploadURL = ""
+	c.Interval = 24 * time.Hour
+	c.Iperf3Address = ""
+	c.MinDownload = 0
+	c.MinUpload = 0
+	c.Probers = []string{SpeedTestProberSpeedTest}
+	c.RefuseBelowMin = false
+	c.Servers = nil
+	c.SkipThreshold = 0.1
+	c.StaticDownload = 0
+	c.StaticUpload = 0
 
 	return c
 }
 
+func (c *SpeedTestConfig) BandwidthProbers() []bandwidth.BandwidthProber {
+	items := make([]bandwidth.BandwidthProber, 0, len(c.Probers))
+	for _, prober := range c.Probers {
+		switch prober {
+		case SpeedTestProberSpeedTest:
+			items = append(items, bandwidth.NewSpeedTestProber(c.Servers...))
+		case SpeedTestProberHTTP:
+			items = append(items, bandwidth.NewHTTPProber(c.HTTPDownloadURL, c.HTTPUploadURL, c.Duration))
+		case SpeedTestProberIperf3:
+			items = append(items, bandwidth.NewIperf3Prober(c.Iperf3Address, c.Duration))
+		case SpeedTestProberStatic:
+			items = append(items, bandwidth.NewStaticProber(MegabitsToBytes(c.StaticUpload), MegabitsToBytes(c.StaticDownload)))
+		}
+	}
+
+	return items
+}
+
+// CheckMin returns an error when the result is below the minimum speeds.
+func (c *SpeedTestConfig) CheckMin(upload, download int64) error {
+	if minimum := MegabitsToBytes(c.MinDownload); download < minimum {
+		return fmt.Errorf("download speed %d B/s is below the minimum %d B/s", download, minimum)
+	}
+	if minimum := MegabitsToBytes(c.MinUpload); upload < minimum {
+		return fmt.Errorf("upload speed %d B/s is below the minimum %d B/s", upload, minimum)
+	}
+
+	return nil
+}
+
+func MegabitsToBytes(v float64) int64 {
+	return int64(v * 1e6 / 8)
+}
+
 type Config struct {
+	Admin     *AdminConfig     `json:"admin" mapstructure:"admin"`
+	Balance   *BalanceConfig   `json:"balance" mapstructure:"balance"`
+	Cache     *CacheConfig     `json:"cache" mapstructure:"cache"`
 	Chain     *ChainConfig     `json:"chain" mapstructure:"chain"`
+	GeoIP     *GeoIPConfig     `json:"geoip" mapstructure:"geoip"`
 	Handshake *HandshakeConfig `json:"handshake" mapstructure:"handshake"`
 	Keyring   *KeyringConfig   `json:"keyring" mapstructure:"keyring"`
+	Metrics   *MetricsConfig   `json:"metrics" mapstructure:"metrics"`
 	Node      *NodeConfig      `json:"node" mapstructure:"node"`
+	Pricing   *PricingConfig   `json:"pricing" mapstructure:"pricing"`
 	QOS       *QOSConfig       `json:"qos" mapstructure:"qos"`
+	SpeedTest *SpeedTestConfig `json:"speedtest" mapstructure:"speedtest"`
 }
 
 func NewConfig() *Config {
 	return &Config{
+		Admin:     NewAdminConfig(),
+		Balance:   NewBalanceConfig(),
+		Cache:     NewCacheConfig(),
 		Chain:     NewChainConfig(),
+		GeoIP:     NewGeoIPConfig(),
 		Handshake: NewHandshakeConfig(),
 		Keyring:   NewKeyringConfig(),
+		Metrics:   NewMetricsConfig(),
 		Node:      NewNodeConfig(),
+		Pricing:   NewPricingConfig(),
 		QOS:       NewQOSConfig(),
+		SpeedTest: NewSpeedTestConfig(),
 	}
 }
 
 func (c *Config) Validate() error {
+	if err := c.Admin.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section admin")
+	}
+	if err := c.Balance.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section balance")
+	}
+	if err := c.Cache.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section cache")
+	}
 	if err := c.Chain.Validate(); err != nil {
 		return errors.Wrapf(err, "invalid section chain")
 	}
+	if err := c.GeoIP.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section geoip")
+	}
 	if err := c.Handshake.Validate(); err != nil {
 		return errors.Wrapf(err, "invalid section handshake")
 	}
 	if err := c.Keyring.Validate(); err != nil {
 		return errors.Wrapf(err, "invalid section keyring")
 	}
+	if err := c.Metrics.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section metrics")
+	}
 	if err := c.Node.Validate(); err != nil {
 		return errors.Wrapf(err, "invalid section node")
 	}
+	if err := c.Pricing.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section pricing")
+	}
 	if err := c.QOS.Validate(); err != nil {
 		return errors.Wrapf(err, "invalid section qos")
 	}
+	if err := c.SpeedTest.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid section speedtest")
+	}
+
+	for _, provider := range c.GeoIP.Providers {
+		if provider == GeoIPProviderMaxMind || provider == GeoIPProviderStatic {
+			if c.Node.IPv4Address == "" {
+				return errors.Wrapf(fmt.Errorf("ipv4_address cannot be empty with provider %s", provider), "invalid section node")
+			}
+		}
+	}
 
 	if c.Node.Type == "v2ray" {
 		if c.Handshake.Enable {
 			return errors.Wrapf(errors.New("must be disabled"), "invalid section handshake")
 		}
+		if c.QOS.IsRateLimited() {
+			return errors.Wrapf(errors.New("rates are not supported by v2ray"), "invalid section qos")
+		}
 	}
 
 	return nil
 }
 
 func (c *Config) WithDefaultValues() *Config {
+	c.Admin = c.Admin.WithDefaultValues()
+	c.Balance = c.Balance.WithDefaultValues()
+	c.Cache = c.Cache.WithDefaultValues()
 	c.Chain = c.Chain.WithDefaultValues()
+	c.GeoIP = c.GeoIP.WithDefaultValues()
 	c.Handshake = c.Handshake.WithDefaultValues()
 	c.Keyring = c.Keyring.WithDefaultValues()
+	c.Metrics = c.Metrics.WithDefaultValues()
 	c.Node = c.Node.WithDefaultValues()
+	c.Pricing = c.Pricing.WithDefaultValues()
 	c.QOS = c.QOS.WithDefaultValues()
+	c.SpeedTest = c.SpeedTest.WithDefaultValues()
 
 	return c
 }
@@ -0,0 +1,177 @@
+package types
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	EarningTypeNode = "node"
+	EarningTypePlan = "plan"
+
+	EarningGroupByAccount      = "account"
+	EarningGroupByDay          = "day"
+	EarningGroupBySubscription = "subscription"
+)
+
+// Earning is a ledger entry for a session proof submitted to the chain. The
+// bytes and the duration are the parts added since the previous proof of the
+// session, and the payout is the amount they earned at the prices in effect.
+type Earning struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time     `json:"timestamp" gorm:"index:idx_earnings_created_at"`
+	Session       uint64        `json:"session" gorm:"index:idx_earnings_session"`
+	Subscription  uint64        `json:"subscription" gorm:"index:idx_earnings_subscription"`
+	Plan          uint64        `json:"plan,omitempty"`
+	Address       string        `json:"address" gorm:"index:idx_earnings_address"`
+	Type          string        `json:"type"`
+	Bytes         int64         `json:"bytes"`
+	Duration      time.Duration `json:"duration"`
+	TotalBytes    int64         `json:"total_bytes"`
+	TotalDuration time.Duration `json:"total_duration"`
+	GigabytePrice string        `json:"gigabyte_price"`
+	HourlyPrice   string        `json:"hourly_price"`
+	Payout        string        `json:"payout"`
+}
+
+// NewEarning returns the entry of the proof following the previous entry of
+// the session, which is empty for the first proof.
+func NewEarning(proof *QueuedProof, prev *Earning) *Earning {
+	var (
+		bytes    = proof.Upload + proof.Download
+		duration = proof.Duration
+	)
+
+	item := &Earning{
+		Session:       proof.Session,
+		Subscription:  proof.Subscription,
+		Address:       proof.Address,
+		TotalBytes:    bytes,
+		TotalDuration: duration,
+	}
+
+	if bytes > prev.TotalBytes {
+		item.Bytes = bytes - prev.TotalBytes
+	}
+	if duration > prev.TotalDuration {
+		item.Duration = duration - prev.TotalDuration
+	}
+
+	return item
+}
+
+// GetPayout returns the payout of the entry, ignoring a malformed value.
+func (e *Earning) GetPayout() sdk.Coins {
+	v, err := sdk.ParseCoinsNormalized(e.Payout)
+	if err != nil {
+		return nil
+	}
+
+	return v
+}
+
+type EarningSummary struct {
+	Key      string        `json:"key"`
+	Sessions int           `json:"sessions"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration"`
+	Payout   string        `json:"payout"`
+}
+
+func ValidateEarningGroupBy(v string) error {
+	switch v {
+	case EarningGroupByAccount, EarningGroupByDay, EarningGroupBySubscription:
+		return nil
+	default:
+		return fmt.Errorf("invalid group %s; expected one of %s, %s or %s", v,
+			EarningGroupByAccount, EarningGroupByDay, EarningGroupBySubscription)
+	}
+}
+
+func earningKey(item *Earning, groupBy string) string {
+	switch groupBy {
+	case EarningGroupByAccount:
+		return item.Address
+	case EarningGroupBySubscription:
+		return strconv.FormatUint(item.Subscription, 10)
+	default:
+		return item.CreatedAt.UTC().Format(time.DateOnly)
+	}
+}
+
+// AggregateEarnings sums the entries per day, account or subscription.
+func AggregateEarnings(items []Earning, groupBy string) ([]EarningSummary, error) {
+	if err := ValidateEarningGroupBy(groupBy); err != nil {
+		return nil, err
+	}
+
+	var (
+		payouts  = make(map[string]sdk.Coins)
+		sessions = make(map[string]map[uint64]bool)
+		summary  = make(map[string]*EarningSummary)
+	)
+
+	for i := range items {
+		key := earningKey(&items[i], groupBy)
+
+		s, ok := summary[key]
+		if !ok {
+			s = &EarningSummary{Key: key}
+			summary[key] = s
+			sessions[key] = make(map[uint64]bool)
+		}
+
+		s.Bytes += items[i].Bytes
+		s.Duration += items[i].Duration
+		sessions[key][items[i].Session] = true
+		payouts[key] = payouts[key].Add(items[i].GetPayout()...)
+	}
+
+	result := make([]EarningSummary, 0, len(summary))
+	for key, s := range summary {
+		s.Sessions = len(sessions[key])
+		s.Payout = payouts[key].String()
+		result = append(result, *s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if groupBy == EarningGroupBySubscription {
+			x, _ := strconv.ParseUint(result[i].Key, 10, 64)
+			y, _ := strconv.ParseUint(result[j].Key, 10, 64)
+			return x < y
+		}
+
+		return result[i].Key < result[j].Key
+	})
+
+	return result, nil
+}
+
+func WriteEarningsCSV(w io.Writer, groupBy string, items ...EarningSummary) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{groupBy, "sessions", "bytes", "duration_seconds", "payout"}); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		err := cw.Write([]string{
+			item.Key,
+			strconv.Itoa(item.Sessions),
+			strconv.FormatInt(item.Bytes, 10),
+			strconv.FormatInt(int64(item.Duration.Seconds()), 10),
+			item.Payout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
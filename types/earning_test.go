@@ -0,0 +1,98 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAggregateEarnings(t *testing.T) {
+	var (
+		day       = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+		dayBefore = day.Add(-time.Nanosecond)
+		eastern   = time.FixedZone("UTC+5", 5*60*60)
+	)
+
+	items := []Earning{
+		{CreatedAt: dayBefore, Session: 1, Subscription: 10, Address: "sent1b", Bytes: 100, Duration: time.Minute, Payout: "5udvpn"},
+		{CreatedAt: day, Session: 1, Subscription: 10, Address: "sent1b", Bytes: 200, Duration: time.Minute, Payout: "7udvpn"},
+		{CreatedAt: day.Add(time.Hour), Session: 2, Subscription: 2, Address: "sent1a", Bytes: 50, Duration: 2 * time.Minute, Payout: "3uatom,1udvpn"},
+		// The local time is on the 11th, but the entry belongs to the 10th in UTC
+		{CreatedAt: day.Add(20 * time.Hour).In(eastern), Session: 3, Subscription: 2, Address: "sent1a", Bytes: 25, Payout: "2uatom"},
+		{CreatedAt: day.Add(24 * time.Hour), Session: 3, Subscription: 2, Address: "sent1a", Bytes: 25},
+	}
+
+	tests := []struct {
+		name    string
+		groupBy string
+		want    []EarningSummary
+		wantErr bool
+	}{
+		{
+			name:    "day",
+			groupBy: EarningGroupByDay,
+			want: []EarningSummary{
+				{Key: "2024-03-09", Sessions: 1, Bytes: 100, Duration: time.Minute, Payout: "5udvpn"},
+				{Key: "2024-03-10", Sessions: 3, Bytes: 275, Duration: 3 * time.Minute, Payout: "5uatom,8udvpn"},
+				{Key: "2024-03-11", Sessions: 1, Bytes: 25, Payout: ""},
+			},
+		},
+		{
+			name:    "account",
+			groupBy: EarningGroupByAccount,
+			want: []EarningSummary{
+				{Key: "sent1a", Sessions: 2, Bytes: 100, Duration: 2 * time.Minute, Payout: "5uatom,1udvpn"},
+				{Key: "sent1b", Sessions: 1, Bytes: 300, Duration: 2 * time.Minute, Payout: "12udvpn"},
+			},
+		},
+		{
+			name:    "subscription in numeric order",
+			groupBy: EarningGroupBySubscription,
+			want: []EarningSummary{
+				{Key: "2", Sessions: 2, Bytes: 100, Duration: 2 * time.Minute, Payout: "5uatom,1udvpn"},
+				{Key: "10", Sessions: 1, Bytes: 300, Duration: 2 * time.Minute, Payout: "12udvpn"},
+			},
+		},
+		{
+			name:    "invalid group",
+			groupBy: "week",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AggregateEarnings(items, tt.groupBy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteEarningsCSV(t *testing.T) {
+	items := []EarningSummary{
+		{Key: "2024-03-09", Sessions: 1, Bytes: 100, Duration: 90 * time.Second, Payout: "5udvpn"},
+		{Key: "2024-03-10", Sessions: 3, Bytes: 275, Duration: 3 * time.Minute, Payout: "5uatom,8udvpn"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteEarningsCSV(&buf, EarningGroupByDay, items...); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "day,sessions,bytes,duration_seconds,payout\n" +
+		"2024-03-09,1,100,90,5udvpn\n" +
+		"2024-03-10,3,275,180,\"5uatom,8udvpn\"\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
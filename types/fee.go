@@ -0,0 +1,75 @@
+package types
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+const (
+	FeeTypeRegisterNode     = "register_node"
+	FeeTypeUpdateNodeInfo   = "update_node_info"
+	FeeTypeUpdateNodeStatus = "update_node_status"
+	FeeTypeUpdateSessions   = "update_sessions"
+)
+
+type Fee struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"timestamp" gorm:"index:idx_fees_created_at"`
+	TxHash    string    `json:"tx_hash"`
+	Type      string    `json:"type" gorm:"index:idx_fees_type"`
+	Messages  int       `json:"messages"`
+	GasWanted int64     `json:"gas_wanted"`
+	GasUsed   int64     `json:"gas_used"`
+	Amount    string    `json:"amount"`
+	Payer     string    `json:"payer"`
+}
+
+// FeeForGas returns the fee for the gas limit at the gas prices.
+func FeeForGas(gas int64, gasPrices sdk.DecCoins) sdk.Coins {
+	amount := sdk.NewCoins()
+	for _, price := range gasPrices {
+		v := price.Amount.MulInt64(gas).Ceil().TruncateInt()
+		amount = amount.Add(sdk.NewCoin(price.Denom, v))
+	}
+
+	return amount
+}
+
+// NewFee returns the fee paid for the transaction, which is the fee in the
+// auth info it was signed with. The responses of the sync and async modes do
+// not report the gas wanted, so the gas limit is taken from the auth info as
+// well, falling back to the gas wanted at the gas prices when the response
+// does not carry the transaction.
+func NewFee(t string, messages int, res *sdk.TxResponse, gasPrices sdk.DecCoins, payer sdk.AccAddress) *Fee {
+	var tx *txtypes.Tx
+	if res.Tx != nil {
+		tx, _ = res.GetTx().(*txtypes.Tx)
+	}
+
+	gas, amount := res.GasWanted, FeeForGas(res.GasWanted, gasPrices)
+	if fee := tx.GetAuthInfo().GetFee(); fee != nil {
+		gas, amount = int64(fee.GasLimit), fee.Amount
+	}
+
+	return &Fee{
+		TxHash:    res.TxHash,
+		Type:      t,
+		Messages:  messages,
+		GasWanted: gas,
+		GasUsed:   res.GasUsed,
+		Amount:    amount.String(),
+		Payer:     payer.String(),
+	}
+}
+
+// GetAmount returns the amount of the fee, ignoring a malformed value.
+func (f *Fee) GetAmount() sdk.Coins {
+	v, err := sdk.ParseCoinsNormalized(f.Amount)
+	if err != nil {
+		return nil
+	}
+
+	return v
+}
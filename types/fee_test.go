@@ -0,0 +1,62 @@
+package types
+
+import (
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+func TestNewFee(t *testing.T) {
+	gasPrices := sdk.NewDecCoins(sdk.NewDecCoinFromDec("udvpn", sdk.MustNewDecFromStr("0.1")))
+
+	signed, err := codectypes.NewAnyWithValue(
+		&txtypes.Tx{
+			AuthInfo: &txtypes.AuthInfo{
+				Fee: &txtypes.Fee{
+					Amount:   sdk.NewCoins(sdk.NewInt64Coin("udvpn", 25_000)),
+					GasLimit: 250_000,
+				},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		res       *sdk.TxResponse
+		gasWanted int64
+		amount    string
+	}{
+		{
+			name:      "sync mode with the signed transaction",
+			res:       &sdk.TxResponse{Tx: signed},
+			gasWanted: 250_000,
+			amount:    "25000udvpn",
+		},
+		{
+			name:      "block mode with the signed transaction",
+			res:       &sdk.TxResponse{Tx: signed, GasWanted: 250_000, GasUsed: 120_000},
+			gasWanted: 250_000,
+			amount:    "25000udvpn",
+		},
+		{
+			name:      "without the transaction",
+			res:       &sdk.TxResponse{GasWanted: 100_001},
+			gasWanted: 100_001,
+			amount:    "10001udvpn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee := NewFee(FeeTypeUpdateSessions, 1, tt.res, gasPrices, sdk.AccAddress{})
+			if fee.GasWanted != tt.gasWanted || fee.Amount != tt.amount {
+				t.Fatalf("expected %d gas and %s, got %d gas and %s", tt.gasWanted, tt.amount, fee.GasWanted, fee.Amount)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ const (
 	ConfigFileName   = "config.toml"
 	ContentType      = "application/json; charset=utf-8"
 	DatabaseFileName = "data.db"
+	GeoIPFileName    = "geoip.json"
 	IPv4CIDR         = "10.8.0.2/24"
 	IPv6CIDR         = "fd86:ea04:1115::2/120"
 	KeyringName      = "sentinel"
@@ -18,6 +19,20 @@ const (
 	FlagForce = "force"
 )
 
+const (
+	GeoIPProviderIPAPI   = "ip-api"
+	GeoIPProviderIPInfo  = "ipinfo"
+	GeoIPProviderMaxMind = "maxmind"
+	GeoIPProviderStatic  = "static"
+)
+
+const (
+	SpeedTestProberHTTP      = "http"
+	SpeedTestProberIperf3    = "iperf3"
+	SpeedTestProberSpeedTest = "speedtest"
+	SpeedTestProberStatic    = "static"
+)
+
 var (
 	DefaultHomeDirectory = func() string {
 		home, err := os.UserHomeDir()
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"time"
+)
+
+const (
+	MaxProofAttempts = 5
+)
+
+// QueuedProof is a session proof that failed to reach the chain and is
+// retried with the next batch of proofs.
+type QueuedProof struct {
+	Session      uint64        `json:"session" gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Subscription uint64        `json:"subscription"`
+	Key          string        `json:"key"`
+	Address      string        `json:"address"`
+	Duration     time.Duration `json:"duration"`
+	Upload       int64         `json:"upload"`
+	Download     int64         `json:"download"`
+	Signature    []byte        `json:"signature,omitempty"`
+	Attempts     int           `json:"attempts"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// NewQueuedProof returns the latest proof signed by the client when it trails
+// the counters of the node by no more than the tolerance accepted with it, and
+// the unsigned proof of the node otherwise, so that a client cannot settle the
+// session with an earlier, much smaller proof.
+func NewQueuedProof(item *Session) *QueuedProof {
+	if len(item.Signature) > 0 && !item.TrailedBy(item.SignedDuration, item.SignedDownload, item.SignedUpload) {
+		return &QueuedProof{
+			Session:      item.ID,
+			Subscription: item.Subscription,
+			Key:          item.Key,
+			Address:      item.Address,
+			Duration:     item.SignedDuration,
+			Upload:       item.SignedUpload,
+			Download:     item.SignedDownload,
+			Signature:    item.Signature,
+		}
+	}
+
+	return &QueuedProof{
+		Session:      item.ID,
+		Subscription: item.Subscription,
+		Key:          item.Key,
+		Address:      item.Address,
+		Duration:     item.Duration(),
+		Upload:       item.Upload,
+		Download:     item.Download,
+	}
+}
+
+func (p *QueuedProof) GetSession() *Session {
+	return &Session{
+		ID:           p.Session,
+		Subscription: p.Subscription,
+		Key:          p.Key,
+		Address:      p.Address,
+		Upload:       p.Upload,
+		Download:     p.Download,
+	}
+}
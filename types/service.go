@@ -7,14 +7,22 @@ type Service interface {
 	Start() error
 	Stop() error
 	AddPeer(data []byte) ([]byte, error)
+	GetPeer(data []byte) (Peer, bool)
 	HasPeer(data []byte) bool
 	RemovePeer(data []byte) error
+	RestorePeers(items []Peer) error
+	SetPeerRate(data []byte, download, upload int64) error
 	Peers() ([]Peer, error)
 	PeerCount() int
 }
 
 type Peer struct {
 	Key      string `json:"key"`
+	IPv4     string `json:"ipv4,omitempty"`
+	IPv6     string `json:"ipv6,omitempty"`
 	Upload   int64  `json:"upload"`
 	Download int64  `json:"download"`
+
+	DownloadRate int64 `json:"download_rate,omitempty"`
+	UploadRate   int64 `json:"upload_rate,omitempty"`
 }
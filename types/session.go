@@ -1,19 +1,40 @@
 package types
 
 import (
+	"time"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"gorm.io/gorm"
 )
 
+const (
+	// The clients sign their proofs at intervals, so a proof may trail the
+	// counters of the node by the traffic in between, but not by more than a
+	// tenth of the counters or the slack, whichever is larger.
+	ProofToleranceRatio    = 0.1
+	ProofToleranceBytes    = 1 << 20
+	ProofToleranceDuration = 1 * time.Minute
+)
+
 type Session struct {
 	gorm.Model
 	ID           uint64 `gorm:"primaryKey;uniqueIndex:idx_sessions_id"`
 	Subscription uint64 `gorm:"index:idx_sessions_subscription_address"`
 	Key          string `gorm:"uniqueIndex:idx_sessions_key"`
 	Address      string `gorm:"index:idx_sessions_address;index:idx_sessions_subscription_address"`
+	IPv4         string `gorm:"column:ipv4"`
+	IPv6         string `gorm:"column:ipv6"`
 	Available    int64
 	Download     int64
 	Upload       int64
+	DownloadRate int64
+	UploadRate   int64
+
+	// The latest proof acknowledged and signed by the client
+	SignedDuration time.Duration
+	SignedDownload int64
+	SignedUpload   int64
+	Signature      []byte
 }
 
 func (s *Session) GetAddress() sdk.AccAddress {
@@ -28,3 +49,25 @@ func (s *Session) GetAddress() sdk.AccAddress {
 
 	return v
 }
+
+// Duration returns the duration of the session measured by the node.
+func (s *Session) Duration() time.Duration {
+	return s.UpdatedAt.Sub(s.CreatedAt)
+}
+
+// TrailedBy reports whether a proof falls short of the counters of the node by
+// more than the tolerance.
+func (s *Session) TrailedBy(duration time.Duration, download, upload int64) bool {
+	below := func(v, counter, slack int64) bool {
+		deficit := int64(float64(counter) * ProofToleranceRatio)
+		if deficit < slack {
+			deficit = slack
+		}
+
+		return v < counter-deficit
+	}
+
+	return below(int64(duration), int64(s.Duration()), int64(ProofToleranceDuration)) ||
+		below(download, s.Download, ProofToleranceBytes) ||
+		below(upload, s.Upload, ProofToleranceBytes)
+}
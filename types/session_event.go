@@ -0,0 +1,40 @@
+package types
+
+import (
+	"time"
+)
+
+const (
+	SessionEventAddPeer            = "add_peer"
+	SessionEventRemovePeer         = "remove_peer"
+	SessionEventAllocationExceeded = "allocation_exceeded"
+	SessionEventStalePeer          = "stale_peer"
+	SessionEventStatusChange       = "status_change"
+	SessionEventProofSubmitted     = "proof_submitted"
+	SessionEventProofFailed        = "proof_failed"
+	SessionEventProofDropped       = "proof_dropped"
+)
+
+type SessionEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"timestamp" gorm:"index:idx_session_events_created_at"`
+	Session   uint64    `json:"session" gorm:"index:idx_session_events_session"`
+	Key       string    `json:"key" gorm:"index:idx_session_events_key"`
+	Address   string    `json:"address" gorm:"index:idx_session_events_address"`
+	Type      string    `json:"type"`
+	Upload    int64     `json:"upload"`
+	Download  int64     `json:"download"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+func NewSessionEvent(t string, item *Session, reason string) *SessionEvent {
+	return &SessionEvent{
+		Session:  item.ID,
+		Key:      item.Key,
+		Address:  item.Address,
+		Type:     t,
+		Upload:   item.Upload,
+		Download: item.Download,
+		Reason:   reason,
+	}
+}
@@ -0,0 +1,15 @@
+package types
+
+import (
+	"time"
+)
+
+type SpeedTest struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time     `json:"timestamp" gorm:"index:idx_speed_tests_created_at"`
+	Prober    string        `json:"prober"`
+	Upload    int64         `json:"upload"`
+	Download  int64         `json:"download"`
+	Latency   time.Duration `json:"latency"`
+	Jitter    time.Duration `json:"jitter"`
+}
@@ -1,15 +1,27 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/soheilhy/cmux"
 )
 
-func ListenAndServeTLS(address, certFile, keyFile string, handler http.Handler) error {
+const (
+	ShutdownTimeout = 15 * time.Second
+)
+
+func ListenAndServeTLS(ctx context.Context, address, certFile, keyFile string, handler http.Handler) error {
 	l, err := net.Listen("tcp", address)
 	if err != nil {
 		return err
@@ -17,6 +29,7 @@ func ListenAndServeTLS(address, certFile, keyFile string, handler http.Handler)
 
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
+		_ = l.Close()
 		return err
 	}
 
@@ -24,10 +37,14 @@ func ListenAndServeTLS(address, certFile, keyFile string, handler http.Handler)
 		mux    = cmux.New(l)
 		tlsMux = mux.Match(cmux.TLS())
 		anyMux = mux.Match(cmux.Any())
+
+		tlsServer = &http.Server{Handler: handler}
+		anyServer = &http.Server{Handler: handler}
+		errC      = make(chan error, 3)
 	)
 
 	go func() {
-		if err := http.Serve(
+		errC <- tlsServer.Serve(
 			tls.NewListener(
 				tlsMux,
 				&tls.Config{
@@ -37,20 +54,164 @@ func ListenAndServeTLS(address, certFile, keyFile string, handler http.Handler)
 					Rand: rand.Reader,
 				},
 			),
-			handler,
-		); err != nil {
-			panic(err)
-		}
+		)
 	}()
 
 	go func() {
-		if err := http.Serve(
-			anyMux,
-			handler,
-		); err != nil {
-			panic(err)
+		errC <- anyServer.Serve(anyMux)
+	}()
+
+	go func() {
+		errC <- mux.Serve()
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err = <-errC:
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	if e := tlsServer.Shutdown(sctx); e != nil && err == nil {
+		err = e
+	}
+	if e := anyServer.Shutdown(sctx); e != nil && err == nil {
+		err = e
+	}
+
+	mux.Close()
+	_ = l.Close()
+
+	if errors.Is(err, http.ErrServerClosed) || errors.Is(err, cmux.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func ListenAndServe(ctx context.Context, address string, handler http.Handler) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	var (
+		server = &http.Server{Handler: handler}
+		errC   = make(chan error, 1)
+	)
+
+	go func() {
+		errC <- server.Serve(l)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err = <-errC:
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	if e := server.Shutdown(sctx); e != nil && err == nil {
+		err = e
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func ListenAndServeTLSWithClientCA(ctx context.Context, address, certFile, keyFile, clientCAFile string, handler http.Handler) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{
+			cert,
+		},
+		MinVersion: tls.VersionTLS12,
+		Rand:       rand.Reader,
+	}
+
+	if clientCAFile != "" {
+		buf, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return fmt.Errorf("no certificates found in %s", clientCAFile)
 		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = pool
+	}
+
+	l, err := tls.Listen("tcp", address, config)
+	if err != nil {
+		return err
+	}
+
+	var (
+		server = &http.Server{Handler: handler}
+		errC   = make(chan error, 1)
+	)
+
+	go func() {
+		errC <- server.Serve(l)
 	}()
 
-	return mux.Serve()
+	select {
+	case <-ctx.Done():
+	case err = <-errC:
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	if e := server.Shutdown(sctx); e != nil && err == nil {
+		err = e
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// PostJSON sends the value as a JSON body to the URL and expects a 2xx status.
+func PostJSON(ctx context.Context, url string, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return nil
 }